@@ -0,0 +1,103 @@
+package blog
+
+import (
+	"html/template"
+	"io/fs"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ampDisallowed are the elements AMP forbids in post content. They are
+// dropped along with everything inside them.
+var ampDisallowed = map[atom.Atom]bool{
+	atom.Script: true,
+	atom.Iframe: true,
+	atom.Form:   true,
+	atom.Object: true,
+	atom.Embed:  true,
+	atom.Style:  true,
+}
+
+// ampHTML rewrites rendered post HTML into AMP-compatible markup: tags AMP
+// forbids are dropped and images become responsive amp-img elements.
+func ampHTML(content template.HTML) template.HTML {
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(string(content)), body)
+	if err != nil { // only on read errors, which a strings.Reader never has
+		return ""
+	}
+	for _, node := range nodes {
+		body.AppendChild(node)
+	}
+	ampRewrite(body)
+
+	var sb strings.Builder
+	for node := body.FirstChild; node != nil; node = node.NextSibling {
+		html.Render(&sb, node)
+	}
+	return template.HTML(sb.String())
+}
+
+// ampRewrite drops the disallowed elements below n and converts its images.
+func ampRewrite(n *html.Node) {
+	for child := n.FirstChild; child != nil; {
+		next := child.NextSibling
+		switch {
+		case child.Type != html.ElementNode:
+		case ampDisallowed[child.DataAtom]:
+			n.RemoveChild(child)
+		case child.DataAtom == atom.Img:
+			ampImg(child)
+		default:
+			ampRewrite(child)
+		}
+		child = next
+	}
+}
+
+// ampImg turns an img element into a responsive amp-img.
+func ampImg(img *html.Node) {
+	img.Data, img.DataAtom = "amp-img", 0
+	sized := false
+	attrs := img.Attr[:0]
+	for _, attr := range img.Attr {
+		switch attr.Key {
+		case "loading", "decoding":
+			// amp-img lazy-loads on its own and rejects these attributes.
+			continue
+		case "width", "height":
+			sized = true
+		}
+		attrs = append(attrs, attr)
+	}
+	if !sized {
+		// amp-img needs explicit dimensions; these only set the aspect ratio.
+		attrs = append(attrs, html.Attribute{Key: "width", Val: "16"}, html.Attribute{Key: "height", Val: "9"})
+	}
+	img.Attr = append(attrs, html.Attribute{Key: "layout", Val: "responsive"})
+}
+
+// ampCSS returns the site stylesheet for inlining into AMP pages, which may
+// not load external stylesheets or use !important.
+func (b *Blog) ampCSS() template.CSS {
+	data, err := fs.ReadFile(b.staticFS, "static/style.css")
+	if err != nil {
+		return ""
+	}
+	if minified, err := b.minifier.Bytes("text/css", data); err == nil {
+		data = minified
+	}
+	return template.CSS(strings.ReplaceAll(string(data), "!important", ""))
+}
+
+func (b *Blog) ampData(post *Post) map[string]interface{} {
+	return map[string]interface{}{
+		"Title":   post.Title,
+		"Post":    post,
+		"Content": ampHTML(post.HTMLContent),
+		"CSS":     b.ampCSS(),
+		"Config":  b.Config,
+	}
+}
@@ -0,0 +1,65 @@
+package blog
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandlePostAMP(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"photos.md": "---\ntitle: Photos\ndate: 2024-01-27\n---\n![A mountain](/static/mountain.jpg)\n\n<script>alert(1)</script>",
+	})
+	blog.Config.AMP = true
+	blog.Config.BaseURL = "https://cenkcorapci.com"
+	router := blog.Router()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/post/photos/amp/", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+
+	if !strings.Contains(body, "<html amp") {
+		t.Errorf("Expected AMP html tag, got %s", body)
+	}
+	if !strings.Contains(body, `<amp-img src="/static/mountain.jpg" alt="A mountain"`) {
+		t.Errorf("Expected image converted to amp-img, got %s", body)
+	}
+	if strings.Contains(body, "<img") || strings.Contains(body, "alert(1)") {
+		t.Errorf("Expected disallowed markup to be stripped")
+	}
+	if !strings.Contains(body, `<link rel="canonical" href="https://cenkcorapci.com/post/photos/">`) {
+		t.Errorf("Expected canonical link to the regular post")
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/post/photos/", nil))
+	if !strings.Contains(rec.Body.String(), `<link rel="amphtml" href="https://cenkcorapci.com/post/photos/amp/">`) {
+		t.Errorf("Expected amphtml link on the regular post")
+	}
+}
+
+func TestAMPHTML(t *testing.T) {
+	for _, test := range []struct {
+		in, want string
+	}{
+		{`<p><img src="/a.png" alt="a > b" loading="lazy" decoding="async" /></p>`,
+			`<p><amp-img src="/a.png" alt="a &gt; b" width="16" height="9" layout="responsive"></amp-img></p>`},
+		{`<IMG SRC="/b.png" WIDTH="640" height="480">`,
+			`<amp-img src="/b.png" width="640" height="480" layout="responsive"></amp-img>`},
+		{`<p>Before</p><script>document.write("</p><iframe>")</script><p>After</p>`,
+			`<p>Before</p><p>After</p>`},
+		{`<div><style>p { color: red }</style><form><input name="q"/></form>kept</div>`,
+			`<div>kept</div>`},
+		{`<p>Write &lt;script&gt; tags with care.</p>`,
+			`<p>Write &lt;script&gt; tags with care.</p>`},
+	} {
+		if got := string(ampHTML(template.HTML(test.in))); got != test.want {
+			t.Errorf("ampHTML(%q) =\n%s\nwant\n%s", test.in, got, test.want)
+		}
+	}
+}
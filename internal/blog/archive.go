@@ -0,0 +1,51 @@
+package blog
+
+import (
+	"sort"
+	"time"
+)
+
+// ArchiveYear groups a year's posts by month, newest month first.
+type ArchiveYear struct {
+	Year   int
+	Months []ArchiveMonth
+}
+
+// ArchiveMonth holds a month's posts, newest first.
+type ArchiveMonth struct {
+	Month time.Month
+	Posts []*Post
+}
+
+// archive groups all posts by year and then month, newest first at every level.
+func (b *Blog) archive() []ArchiveYear {
+	posts := make([]*Post, len(b.postList))
+	copy(posts, b.postList)
+	sort.SliceStable(posts, func(i, j int) bool {
+		return posts[i].Date.After(posts[j].Date)
+	})
+
+	var years []ArchiveYear
+	for _, post := range posts {
+		year, month := post.Date.Year(), post.Date.Month()
+		if len(years) == 0 || years[len(years)-1].Year != year {
+			years = append(years, ArchiveYear{Year: year})
+		}
+		y := &years[len(years)-1]
+		if len(y.Months) == 0 || y.Months[len(y.Months)-1].Month != month {
+			y.Months = append(y.Months, ArchiveMonth{Month: month})
+		}
+		m := &y.Months[len(y.Months)-1]
+		m.Posts = append(m.Posts, post)
+	}
+	return years
+}
+
+func (b *Blog) archiveData() map[string]interface{} {
+	return map[string]interface{}{
+		"Title":   "Archive",
+		"Archive": b.archive(),
+		"Config":  b.Config,
+		"Theme":   b.Config.DefaultTheme,
+	}
+}
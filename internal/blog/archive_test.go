@@ -0,0 +1,42 @@
+package blog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestArchiveGrouping(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"a.md": "---\ntitle: A\ndate: 2022-03-10\n---\nBody",
+		"b.md": "---\ntitle: B\ndate: 2024-01-05\n---\nBody",
+		"c.md": "---\ntitle: C\ndate: 2024-01-20\n---\nBody",
+		"d.md": "---\ntitle: D\ndate: 2024-06-01\n---\nBody",
+		"e.md": "---\ntitle: E\ndate: 2022-11-30\n---\nBody",
+	})
+
+	years := blog.archive()
+	if len(years) != 2 || years[0].Year != 2024 || years[1].Year != 2022 {
+		t.Fatalf("Expected years [2024 2022], got %+v", years)
+	}
+
+	months2024 := years[0].Months
+	if len(months2024) != 2 || months2024[0].Month != time.June || months2024[1].Month != time.January {
+		t.Fatalf("Expected 2024 months [June January], got %+v", months2024)
+	}
+	if len(months2024[1].Posts) != 2 || months2024[1].Posts[0].ID != "c" || months2024[1].Posts[1].ID != "b" {
+		t.Errorf("Expected January 2024 posts [c b], got %v", months2024[1].Posts)
+	}
+
+	months2022 := years[1].Months
+	if len(months2022) != 2 || months2022[0].Month != time.November || len(months2022[1].Posts) != 1 {
+		t.Errorf("Unexpected 2022 grouping %+v", months2022)
+	}
+
+	rec := httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/archive/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200 from /archive/, got %d", rec.Code)
+	}
+}
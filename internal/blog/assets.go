@@ -0,0 +1,130 @@
+package blog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// contentHash returns a short hex digest of data for use in asset names
+// and ETags.
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// hashedName inserts the content hash of data before the extension of name,
+// e.g. style.css becomes style.1a2b3c4d5e6f.css.
+func hashedName(name string, data []byte) string {
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + contentHash(data) + ext
+}
+
+// assetPath returns the URL of the static file name. Exported pages pass a
+// manifest mapping original names to hashed ones; the live server passes
+// none and keeps the stable name.
+func assetPath(manifest map[string]string, name string) string {
+	if hashed, ok := manifest[name]; ok {
+		return "/static/" + hashed
+	}
+	return "/static/" + name
+}
+
+// exportStaticAssets minifies the static files into sink under
+// static/<hashed name> and returns the manifest from original to hashed name.
+func (b *Blog) exportStaticAssets(sink exportSink) (map[string]string, error) {
+	manifest := make(map[string]string)
+	var errs []error
+	entries, _ := fs.ReadDir(b.staticFS, "static")
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		data, _ := fs.ReadFile(b.staticFS, "static/"+entry.Name())
+
+		minified := data
+		switch filepath.Ext(entry.Name()) {
+		case ".css":
+			minified, _ = b.minifier.Bytes("text/css", data)
+		case ".js":
+			minified, _ = b.minifier.Bytes("text/javascript", data)
+		}
+
+		name := hashedName(entry.Name(), minified)
+		if err := sink("static/"+name, writeBytes(minified)); err != nil {
+			errs = append(errs, err)
+		}
+		manifest[entry.Name()] = name
+	}
+	return manifest, errors.Join(errs...)
+}
+
+// staticETags computes a strong ETag for every file under static/.
+func staticETags(staticFS fs.FS) map[string]string {
+	etags := make(map[string]string)
+	fs.WalkDir(staticFS, "static", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if data, err := fs.ReadFile(staticFS, path); err == nil {
+			etags[strings.TrimPrefix(path, "static/")] = `"` + contentHash(data) + `"`
+		}
+		return nil
+	})
+	return etags
+}
+
+// staticContentTypes maps the extensions of files the blog ships under
+// static/ to their MIME types. Go's own table misses some of them, and
+// files it cannot identify are served as application/octet-stream.
+var staticContentTypes = map[string]string{
+	".css":         "text/css; charset=utf-8",
+	".js":          "text/javascript; charset=utf-8",
+	".json":        "application/json",
+	".webmanifest": "application/manifest+json",
+	".xml":         "application/xml; charset=utf-8",
+	".txt":         "text/plain; charset=utf-8",
+	".svg":         "image/svg+xml",
+	".png":         "image/png",
+	".jpg":         "image/jpeg",
+	".jpeg":        "image/jpeg",
+	".gif":         "image/gif",
+	".webp":        "image/webp",
+	".avif":        "image/avif",
+	".ico":         "image/x-icon",
+	".woff":        "font/woff",
+	".woff2":       "font/woff2",
+	".pdf":         "application/pdf",
+	".mp3":         "audio/mpeg",
+	".m4a":         "audio/mp4",
+	".ogg":         "audio/ogg",
+	".mp4":         "video/mp4",
+	".webm":        "video/webm",
+	".zip":         "application/zip",
+}
+
+// withContentTypes sets the Content-Type of known static files before the
+// file server would guess it.
+func withContentTypes(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if contentType, ok := staticContentTypes[strings.ToLower(filepath.Ext(r.URL.Path))]; ok {
+			w.Header().Set("Content-Type", contentType)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withETags sets the precomputed ETag for the requested static file so the
+// file server can answer conditional requests with 304 Not Modified.
+func withETags(etags map[string]string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if etag, ok := etags[strings.TrimPrefix(r.URL.Path, "/")]; ok {
+			w.Header().Set("ETag", etag)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
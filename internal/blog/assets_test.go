@@ -0,0 +1,156 @@
+package blog
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestExportStaticAssetsManifest(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHello.",
+	})
+	files := make(map[string][]byte)
+
+	manifest, err := blog.exportStaticAssets(memorySink(files))
+	if err != nil {
+		t.Fatalf("Failed to export static assets: %v", err)
+	}
+
+	hashed := regexp.MustCompile(`^style\.[0-9a-f]{12}\.css$`)
+	if !hashed.MatchString(manifest["style.css"]) {
+		t.Fatalf("Expected style.css to map to a hashed name, got %q", manifest["style.css"])
+	}
+	for original, name := range manifest {
+		if _, ok := files["static/"+name]; !ok {
+			t.Errorf("Expected %s to be written as %s", original, name)
+		}
+		if _, ok := files["static/"+original]; ok {
+			t.Errorf("Expected unhashed %s not to be written", original)
+		}
+	}
+}
+
+func TestExportReferencesHashedAssets(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHello.",
+	})
+	distDir := t.TempDir()
+	blog.Export(distDir)
+
+	html, err := os.ReadFile(filepath.Join(distDir, "post", "hello", "index.html"))
+	if err != nil {
+		t.Fatalf("Failed to read exported post: %v", err)
+	}
+	if strings.Contains(string(html), "/static/style.css") {
+		t.Errorf("Expected exported page to reference the hashed stylesheet")
+	}
+	if !regexp.MustCompile(`/static/style\.[0-9a-f]{12}\.css`).Match(html) {
+		t.Errorf("Expected hashed stylesheet link in exported page")
+	}
+}
+
+func TestStaticETag(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHello.",
+	})
+	router := blog.Router()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/static/style.css", nil))
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag == "" || strings.HasPrefix(etag, "W/") {
+		t.Fatalf("Expected 200 with a strong ETag, got %d and %q", rec.Code, etag)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/static/style.css", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("Expected 304 for a matching If-None-Match, got %d", rec.Code)
+	}
+}
+
+func TestStaticContentTypes(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHello.",
+	})
+	blog.staticFS = fstest.MapFS{
+		"static/site.webmanifest": {Data: []byte(`{"name":"Blog"}`)},
+		"static/photo.avif":       {Data: []byte("not really an image")},
+		"static/style.css":        {Data: []byte("body{}")},
+		"static/episode.mp3":      {Data: []byte("not really audio")},
+	}
+	router := blog.Router()
+
+	for path, want := range map[string]string{
+		"/static/site.webmanifest": "application/manifest+json",
+		"/static/photo.avif":       "image/avif",
+		"/static/style.css":        "text/css; charset=utf-8",
+		"/static/episode.mp3":      "audio/mpeg",
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: expected status 200, got %d", path, rec.Code)
+		}
+		if got := rec.Header().Get("Content-Type"); got != want {
+			t.Errorf("%s: expected Content-Type %q, got %q", path, want, got)
+		}
+	}
+}
+
+func TestStaticRangeRequests(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHello.",
+	})
+	pdf := bytes.Repeat([]byte("%PDF-1.7 0123456789\n"), 500)
+	blog.staticFS = fstest.MapFS{"static/talk.pdf": {Data: pdf}}
+	blog.staticETags = staticETags(blog.staticFS)
+	router := blog.Router()
+	get := func(header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/static/talk.pdf", nil)
+		for i := 0; i < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get()
+	if rec.Header().Get("Accept-Ranges") != "bytes" || rec.Header().Get("Content-Type") != "application/pdf" {
+		t.Errorf("Expected a PDF advertising byte ranges, got %v", rec.Header())
+	}
+
+	rec = get("Range", "bytes=0-99")
+	if rec.Code != http.StatusPartialContent {
+		t.Fatalf("Expected 206 for a range request, got %d", rec.Code)
+	}
+	if got, want := rec.Header().Get("Content-Range"), fmt.Sprintf("bytes 0-99/%d", len(pdf)); got != want {
+		t.Errorf("Expected Content-Range %q, got %q", want, got)
+	}
+	if !bytes.Equal(rec.Body.Bytes(), pdf[:100]) || rec.Header().Get("Content-Length") != "100" {
+		t.Errorf("Expected the first 100 bytes, got %d", rec.Body.Len())
+	}
+
+	// Resuming a download only gets the rest while the file is unchanged.
+	etag := rec.Header().Get("ETag")
+	if rec := get("Range", "bytes=100-", "If-Range", etag); rec.Code != http.StatusPartialContent || !bytes.Equal(rec.Body.Bytes(), pdf[100:]) {
+		t.Errorf("Expected 206 with the rest of the file for a matching If-Range, got %d", rec.Code)
+	}
+	if rec := get("Range", "bytes=100-", "If-Range", `"stale"`); rec.Code != http.StatusOK || rec.Body.Len() != len(pdf) {
+		t.Errorf("Expected the whole file for a stale If-Range, got %d", rec.Code)
+	}
+	if rec := get("Range", fmt.Sprintf("bytes=%d-", len(pdf))); rec.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Errorf("Expected 416 for a range past the end, got %d", rec.Code)
+	}
+}
@@ -2,19 +2,31 @@ package blog
 
 import (
 	"bytes"
-	"embed"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
-	"log"
+	"io"
+	"io/fs"
+	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
 	mathjax "github.com/litao91/goldmark-mathjax"
 	"github.com/tdewolff/minify/v2"
 	"github.com/tdewolff/minify/v2/css"
@@ -22,34 +34,152 @@ import (
 	"github.com/tdewolff/minify/v2/js"
 	mjson "github.com/tdewolff/minify/v2/json"
 	"github.com/yuin/goldmark"
+	emoji "github.com/yuin/goldmark-emoji"
 	highlighting "github.com/yuin/goldmark-highlighting/v2"
 	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
 	ghml "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
 	"gopkg.in/yaml.v3"
 )
 
 type Post struct {
-	ID          string
-	Title       string
-	Date        time.Time
-	Tags        []string
-	Content     string
-	HTMLContent template.HTML
-	Slug        string
+	ID            string
+	Title         string
+	Date          time.Time
+	Updated       time.Time // last revision; equal to Date unless set
+	Tags          []string
+	Content       string
+	HTMLContent   template.HTML
+	Slug          string
+	OGType        string
+	TOC           []TOCEntry
+	ReadingTime   int // estimated minutes
+	Series        string
+	Excerpt       string // plain-text summary for meta tags and feeds
+	Draft         bool   // unpublished; only served through a preview link
+	Featured      bool   // pinned above the other posts on the home page
+	FeaturedOrder int    // position among featured posts, lowest first
+	HasMath       bool   // the page needs KaTeX
+	Weight        int    // explicit position in listings, lowest first; 0 means unweighted
+	Image         string // social preview image, a URL or a site path
+	Category      string // primary topic; see Category
 }
 
 type Config struct {
-	BlogName     string `yaml:"blog_name"`
-	Introduction string `yaml:"introduction"`
-	BaseURL      string `yaml:"base_url"`
-	LinkedInURL  string `yaml:"linkedin_url"`
-	GitHubURL    string `yaml:"github_url"`
+	BlogName         string   `yaml:"blog_name"`
+	Introduction     string   `yaml:"introduction"`
+	BaseURL          string   `yaml:"base_url"`
+	LinkedInURL      string   `yaml:"linkedin_url"`
+	GitHubURL        string   `yaml:"github_url"`
+	MaxPostSize      int64    `yaml:"max_post_size"` // bytes; larger markdown files are skipped
+	InfixSearch      bool     `yaml:"infix_search"`  // match query words inside indexed words; slower
+	PathDates        bool     `yaml:"path_dates"`    // infer missing dates from blog/YYYY/MM/DD/ folders
+	CodeStyle        string   `yaml:"code_style"`    // chroma style for code blocks
+	BackToTop        bool     `yaml:"back_to_top"`   // show a "back to top" link on posts
+	CodeLineNumbers  bool     `yaml:"code_line_numbers"`
+	AllowedTags      []string `yaml:"allowed_tags"` // when set, other tags are reported
+	StrictTags       bool     `yaml:"strict_tags"`  // fail loading on tags outside AllowedTags
+	ReadingWPM       int      `yaml:"reading_wpm"`  // words per minute for reading-time estimates
+	AMP              bool     `yaml:"amp"`          // also publish AMP versions at /post/{slug}/amp/
+	SearchFallback   bool     `yaml:"search_fallback"`
+	PostsPerPage     int      `yaml:"posts_per_page"`     // default page size for paginated lists
+	LazyImages       bool     `yaml:"lazy_images"`        // lazy-load images and caption them with their alt text
+	HardWraps        *bool    `yaml:"hard_wraps"`         // render single newlines as <br>; nil means true
+	ThemeDir         string   `yaml:"theme_dir"`          // on-disk templates overriding the embedded ones
+	DefaultTheme     string   `yaml:"default_theme"`      // "dark" or "light" for visitors who haven't chosen
+	MinifyHTML       *bool    `yaml:"minify_html"`        // minify exported HTML pages; nil means true
+	CanonicalHost    string   `yaml:"canonical_host"`     // live server redirects other hosts here, e.g. www.example.com
+	Emoji            *bool    `yaml:"emoji"`              // render :shortcodes: as emoji; nil means true
+	CSP              string   `yaml:"csp"`                // Content-Security-Policy for the live server; {nonce} is replaced per request
+	OGImages         bool     `yaml:"og_images"`          // generate preview images for posts without an image
+	Timezone         string   `yaml:"timezone"`           // IANA zone that frontmatter dates are in, e.g. Europe/Amsterdam
+	RateLimit        float64  `yaml:"rate_limit"`         // search API requests per second per client
+	RateBurst        int      `yaml:"rate_burst"`         // search API requests a client may make at once
+	TrustProxy       bool     `yaml:"trust_proxy"`        // identify clients by X-Forwarded-For, schemes by X-Forwarded-Proto
+	MaxSearchResults int      `yaml:"max_search_results"` // cap on the posts a search returns
+	RelativeDateDays int      `yaml:"relative_date_days"` // humanizeDate writes out dates older than this
+	TitleWeight      float64  `yaml:"title_weight"`       // search score for a query word found in a post's title
+	BodyWeight       float64  `yaml:"body_weight"`        // search score for a query word found only in the body
+	Precompress      bool     `yaml:"precompress"`        // export .gz variants of text files for hosts that serve them
+	ContentDir       string   `yaml:"content_dir"`        // directory of the embedded blog FS holding the posts
+	WebSubHub        string   `yaml:"websub_hub"`         // hub the feeds advertise and -ping notifies, e.g. https://pubsubhubbub.appspot.com/
+	DefaultCodeLang  string   `yaml:"default_code_lang"`  // highlight fenced code blocks without a language as this one
+	PreviewFuture    bool     `yaml:"preview_future"`     // publish posts dated in the future instead of holding them back
+	LogSearches      bool     `yaml:"log_searches"`       // log live-server searches as JSON lines and count them for /api/search-stats
+	SearchLog        string   `yaml:"search_log"`         // file the search log is appended to; stdout when empty
+
+	Comments CommentsConfig `yaml:"comments"` // GitHub-backed comments under posts
+}
+
+const (
+	defaultMaxPostSize      = 5 << 20 // 5MB
+	defaultCodeStyle        = "monokai"
+	defaultReadingWPM       = 200
+	defaultPostsPerPage     = 10
+	defaultTheme            = "dark"
+	defaultTimezone         = "UTC"
+	defaultRateLimit        = 5
+	defaultRateBurst        = 20
+	defaultMaxSearchResults = 100
+	defaultRelativeDateDays = 30
+	defaultTitleWeight      = 3
+	defaultBodyWeight       = 1
+	defaultContentDir       = "blog"
+
+	// defaultCSP allows the blog's own assets, inline scripts carrying the
+	// request's nonce, and the CDNs the templates load: KaTeX from
+	// jsDelivr, Google Fonts, GitHub buttons, the AMP runtime and the
+	// comment widgets.
+	defaultCSP = "default-src 'self'; " +
+		"script-src 'self' 'nonce-{nonce}' https://cdn.jsdelivr.net https://buttons.github.io https://cdn.ampproject.org https://giscus.app https://utteranc.es; " +
+		"style-src 'self' 'unsafe-inline' https://cdn.jsdelivr.net https://fonts.googleapis.com; " +
+		"font-src 'self' https://cdn.jsdelivr.net https://fonts.gstatic.com; " +
+		"img-src 'self' data: https:; " +
+		"connect-src 'self' https://api.github.com; " +
+		"frame-src https://buttons.github.io https://giscus.app https://utteranc.es; " +
+		"object-src 'none'; base-uri 'self'; form-action 'self'"
+)
+
+// SearchIndex is the document consumed by static/search.js.
+type SearchIndex struct {
+	Posts         []SearchIndexPost   `json:"posts"`
+	InvertedIndex map[string][]string `json:"invertedIndex"`
+	InfixSearch   bool                `json:"infixSearch"`
+}
+
+// SearchIndexPost is the post metadata shipped in search-index.json.
+type SearchIndexPost struct {
+	ID       string   `json:"id"`
+	Title    string   `json:"title"`
+	Date     string   `json:"date"`
+	Tags     []string `json:"tags"`
+	Slug     string   `json:"slug"`
+	Category string   `json:"category,omitempty"`
+}
+
+func newSearchIndexPost(post *Post) SearchIndexPost {
+	tags := post.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return SearchIndexPost{
+		ID:       post.ID,
+		Title:    post.Title,
+		Date:     post.Date.Format("2006-01-02"),
+		Tags:     tags,
+		Slug:     post.Slug,
+		Category: post.Category,
+	}
 }
 
 type InvertedIndex struct {
-	mu    sync.RWMutex
-	index map[string][]string // map[word][]postIDs
+	mu     sync.RWMutex
+	index  map[string][]string // map[word][]postIDs
+	titles map[string][]string // map[word][]postIDs for words in post titles
+	terms  map[string][]string // map[postID][]words, so a post can be unindexed
 }
 
 type Blog struct {
@@ -59,33 +189,103 @@ type Blog struct {
 	markdown      goldmark.Markdown
 	invertedIndex *InvertedIndex
 	Config        Config
-	templatesFS   embed.FS
-	staticFS      embed.FS
-	blogFS        embed.FS
+	Build         BuildInfo
+	templatesFS   fs.FS
+	staticFS      fs.FS
+	blogFS        fs.FS
 	minifier      *minify.M
+	staticETags   map[string]string
+	prebuiltIndex bool // invertedIndex came from LoadPrebuiltIndex
+	views         *viewCounter
+	drafts        map[string]*Post  // unpublished posts, by ID: drafts and scheduled posts
+	previewSecret []byte            // signs draft preview links; from PREVIEW_SECRET
+	adminSecret   []byte            // unlocks admin-only endpoints; from ADMIN_SECRET
+	searches      *searchLog        // what readers search for, with Config.LogSearches
+	redirects     map[string]string // old post slug -> new location
+	indexCache    searchIndexCache
+	location      *time.Location   // Config.Timezone
+	now           func() time.Time // decides which scheduled posts are due
+	nextPublish   time.Time        // date of the next scheduled post; zero if none
+	publishing    sync.RWMutex     // held for reading while a request is served
+	funcs         template.FuncMap
+	renderer      Renderer
+}
+
+// NewBlog creates a blog configured from config.yaml. Posts are rendered
+// with goldmark unless a renderer is given.
+func NewBlog(templatesFS, staticFS, blogFS fs.FS, custom ...Renderer) (*Blog, error) {
+	return NewBlogWithConfig(loadConfig(), templatesFS, staticFS, blogFS, custom...)
 }
 
-func NewBlog(templatesFS, staticFS, blogFS embed.FS) (*Blog, error) {
+// NewBlogWithConfig is like NewBlog but uses the given config instead of
+// reading config.yaml. Unset fields receive the usual defaults.
+func NewBlogWithConfig(config Config, templatesFS, staticFS, blogFS fs.FS, custom ...Renderer) (*Blog, error) {
+	config = applyConfigDefaults(config)
+
+	highlightOptions := []highlighting.Option{
+		highlighting.WithStyle(config.CodeStyle),
+		highlighting.WithWrapperRenderer(codeBlockWrapper),
+	}
+	if config.CodeLineNumbers {
+		highlightOptions = append(highlightOptions, highlighting.WithFormatOptions(chromahtml.WithLineNumbers(true)))
+	}
+
+	parserOptions := []parser.Option{
+		parser.WithAutoHeadingID(),
+		// Ahead of goldmark's link parser, which also starts at '['.
+		parser.WithInlineParsers(util.Prioritized(wikiLinks{}, 199)),
+		parser.WithASTTransformers(util.Prioritized(newExternalLinks(config.BaseURL), 100)),
+	}
+	rendererOptions := []renderer.Option{
+		ghml.WithXHTML(),
+	}
+	extensions := []goldmark.Extender{
+		extension.GFM,
+		extension.DefinitionList,
+		extension.Footnote,
+	}
+	if config.DefaultCodeLang != "" {
+		codeBlocks := defaultCodeLang{lang: []byte(config.DefaultCodeLang), inner: highlighting.NewHTMLRenderer(highlightOptions...)}
+		rendererOptions = append(rendererOptions, renderer.WithNodeRenderers(util.Prioritized(codeBlocks, 200)))
+	} else {
+		extensions = append(extensions, highlighting.NewHighlighting(highlightOptions...))
+	}
+	extensions = append(extensions, mathjax.MathJax)
+	if *config.Emoji {
+		extensions = append(extensions, emoji.New(emoji.WithRenderingMethod(emoji.Unicode)))
+	}
+	if *config.HardWraps {
+		rendererOptions = append(rendererOptions, ghml.WithHardWraps())
+	}
+	if config.LazyImages {
+		parserOptions = append(parserOptions, parser.WithASTTransformers(util.Prioritized(lazyImages{}, 100)))
+		rendererOptions = append(rendererOptions, renderer.WithNodeRenderers(util.Prioritized(figureRenderer{}, 100)))
+	}
+
 	md := goldmark.New(
-		goldmark.WithExtensions(
-			extension.GFM,
-			highlighting.NewHighlighting(
-				highlighting.WithStyle("monokai"),
-			),
-			mathjax.MathJax,
-		),
-		goldmark.WithParserOptions(
-			parser.WithAutoHeadingID(),
-		),
-		goldmark.WithRendererOptions(
-			ghml.WithHardWraps(),
-			ghml.WithXHTML(),
-		),
+		goldmark.WithExtensions(extensions...),
+		goldmark.WithParserOptions(parserOptions...),
+		goldmark.WithRendererOptions(rendererOptions...),
 	)
 
-	templates, err := template.ParseFS(templatesFS, "templates/*.html")
+	location, err := time.LoadLocation(config.Timezone)
 	if err != nil {
-		log.Printf("Warning: Error loading templates: %v", err)
+		location = time.UTC
+	}
+
+	funcs := blogTemplateFuncs(config.RelativeDateDays, location)
+
+	// An FS without templates (as in some tests) is tolerated; templates
+	// that exist but fail to parse are not.
+	var templates *template.Template
+	if matches, _ := fs.Glob(templatesFS, "templates/*.html"); len(matches) > 0 {
+		parsed, err := template.New("").Funcs(funcs).ParseFS(templatesFS, "templates/*.html")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse templates: %w", err)
+		}
+		templates = parsed
+	} else {
+		slog.Debug("No templates found")
 	}
 
 	m := minify.New()
@@ -94,161 +294,659 @@ func NewBlog(templatesFS, staticFS, blogFS embed.FS) (*Blog, error) {
 	m.AddFunc("text/javascript", js.Minify)
 	m.AddFunc("application/json", mjson.Minify)
 
-	return &Blog{
+	b := &Blog{
 		posts:         make(map[string]*Post),
 		postList:      make([]*Post, 0),
 		templates:     templates,
 		markdown:      md,
-		invertedIndex: &InvertedIndex{index: make(map[string][]string)},
-		Config:        loadConfig(),
+		invertedIndex: newInvertedIndex(),
+		Config:        config,
 		templatesFS:   templatesFS,
 		staticFS:      staticFS,
 		blogFS:        blogFS,
 		minifier:      m,
-	}, nil
+		staticETags:   staticETags(staticFS),
+		views:         newViewCounter(),
+		drafts:        make(map[string]*Post),
+		previewSecret: []byte(os.Getenv(previewSecretEnv)),
+		adminSecret:   []byte(os.Getenv(adminSecretEnv)),
+		searches:      newSearchLog(os.Stdout),
+		location:      location,
+		now:           time.Now,
+		funcs:         funcs,
+		renderer:      goldmarkRenderer{md},
+	}
+	if len(custom) > 0 && custom[0] != nil {
+		b.renderer = custom[0]
+	}
+
+	if config.ThemeDir != "" {
+		if err := b.LoadTheme(config.ThemeDir); err != nil {
+			slog.Warn("Error loading theme, using embedded templates", "err", err)
+		}
+	}
+	return b, nil
+}
+
+// codeBlockWrapper wraps code blocks in a div carrying the fence language,
+// which client-side copy buttons use as a label. Blocks chroma can't highlight
+// arrive as bare text, so they get the <pre><code> goldmark would have written.
+func codeBlockWrapper(w util.BufWriter, ctx highlighting.CodeBlockContext, entering bool) {
+	lang, _ := ctx.Language()
+	if !entering {
+		if !ctx.Highlighted() {
+			w.WriteString("</code></pre>")
+		}
+		w.WriteString("</div>")
+		return
+	}
+	w.WriteString(`<div class="code-block" data-lang="`)
+	w.Write(util.EscapeHTML(lang))
+	w.WriteString(`">`)
+	if !ctx.Highlighted() {
+		w.WriteString("<pre><code")
+		if len(lang) > 0 {
+			w.WriteString(` class="language-`)
+			w.Write(util.EscapeHTML(lang))
+			w.WriteString(`"`)
+		}
+		w.WriteString(">")
+	}
 }
 
 func loadConfig() Config {
 	file, err := os.ReadFile("config.yaml")
 	if err != nil {
-		log.Printf("Warning: Could not read config.yaml, using defaults: %v", err)
-		return Config{
-			BlogName:     "Cenk Corapci",
-			Introduction: "Hello 👋. I'm Cenk. A data engineer living in the Netherlands.",
-			BaseURL:      "https://cenkcorapci.com",
-			LinkedInURL:  "https://linkedin.com/in/cenkcorapci",
-		}
+		slog.Warn("Could not read config.yaml, using defaults", "err", err)
+		return defaultConfig()
+	}
+
+	config, err := parseConfig(file, os.Getenv("APP_ENV"))
+	if err != nil {
+		slog.Warn("Could not parse config.yaml, using defaults", "err", err)
+		return defaultConfig()
 	}
+	return config
+}
+
+func defaultConfig() Config {
+	return applyConfigDefaults(Config{
+		BlogName:     "Cenk Corapci",
+		Introduction: "Hello 👋. I'm Cenk. A data engineer living in the Netherlands.",
+	})
+}
 
+// parseConfig decodes the base config and, when env names a section under
+// `environments`, merges that section's fields over the base values.
+func parseConfig(data []byte, env string) (Config, error) {
 	var config Config
-	if err := yaml.Unmarshal(file, &config); err != nil {
-		log.Printf("Warning: Could not parse config.yaml, using defaults: %v", err)
-		return Config{
-			BlogName:     "Cenk Corapci",
-			Introduction: "Hello 👋. I'm Cenk. A data engineer living in the Netherlands.",
-			BaseURL:      "https://cenkcorapci.com",
-			LinkedInURL:  "https://linkedin.com/in/cenkcorapci",
-		}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return Config{}, err
 	}
 
-	if config.BaseURL == "" {
-		config.BaseURL = "https://cenkcorapci.com"
+	if env != "" {
+		var overrides struct {
+			Environments map[string]yaml.Node `yaml:"environments"`
+		}
+		if err := yaml.Unmarshal(data, &overrides); err != nil {
+			return Config{}, err
+		}
+		if node, ok := overrides.Environments[env]; ok {
+			if err := node.Decode(&config); err != nil {
+				return Config{}, fmt.Errorf("invalid %q environment: %w", env, err)
+			}
+		}
 	}
+
+	return applyConfigDefaults(config), nil
+}
+
+func applyConfigDefaults(config Config) Config {
 	if config.LinkedInURL == "" {
 		config.LinkedInURL = "https://linkedin.com/in/cenkcorapci"
 	}
 	if config.GitHubURL == "" {
 		config.GitHubURL = "https://github.com/cenkcorapci/my-blog"
 	}
+	if config.MaxPostSize <= 0 {
+		config.MaxPostSize = defaultMaxPostSize
+	}
+	if config.ContentDir == "" {
+		config.ContentDir = defaultContentDir
+	} else if dir := path.Clean(config.ContentDir); fs.ValidPath(dir) {
+		config.ContentDir = dir
+	} else {
+		slog.Warn("content_dir must be a relative path inside the module, using default", "content_dir", config.ContentDir, "default", defaultContentDir)
+		config.ContentDir = defaultContentDir
+	}
+	if config.ReadingWPM < 0 {
+		slog.Warn("Invalid reading_wpm, using default", "reading_wpm", config.ReadingWPM, "default", defaultReadingWPM)
+	}
+	if config.ReadingWPM <= 0 {
+		config.ReadingWPM = defaultReadingWPM
+	}
+	if config.PostsPerPage < 0 {
+		slog.Warn("Invalid posts_per_page, using default", "posts_per_page", config.PostsPerPage, "default", defaultPostsPerPage)
+	}
+	if config.PostsPerPage <= 0 {
+		config.PostsPerPage = defaultPostsPerPage
+	}
+	if config.RelativeDateDays < 0 {
+		slog.Warn("Invalid relative_date_days, using default", "relative_date_days", config.RelativeDateDays, "default", defaultRelativeDateDays)
+	}
+	if config.RelativeDateDays <= 0 {
+		config.RelativeDateDays = defaultRelativeDateDays
+	}
+	if config.CodeStyle == "" {
+		config.CodeStyle = defaultCodeStyle
+	} else if _, ok := styles.Registry[config.CodeStyle]; !ok {
+		slog.Warn("Unknown code_style, using default", "code_style", config.CodeStyle, "default", defaultCodeStyle)
+		config.CodeStyle = defaultCodeStyle
+	}
+	if config.DefaultCodeLang != "" && lexers.Get(config.DefaultCodeLang) == nil {
+		slog.Warn("Unknown default_code_lang, leaving blocks without a language plain", "default_code_lang", config.DefaultCodeLang)
+		config.DefaultCodeLang = ""
+	}
+	if config.DefaultTheme == "" {
+		config.DefaultTheme = defaultTheme
+	} else if !validTheme(config.DefaultTheme) {
+		slog.Warn("Unknown default_theme, using default", "default_theme", config.DefaultTheme, "default", defaultTheme)
+		config.DefaultTheme = defaultTheme
+	}
+	if config.HardWraps == nil {
+		hardWraps := true
+		config.HardWraps = &hardWraps
+	}
+	if strings.ContainsAny(config.CanonicalHost, "/ ") {
+		slog.Warn("canonical_host must be a bare host name, ignoring it", "canonical_host", config.CanonicalHost)
+		config.CanonicalHost = ""
+	}
+	config.CanonicalHost = strings.ToLower(config.CanonicalHost)
+	if config.Emoji == nil {
+		emoji := true
+		config.Emoji = &emoji
+	}
+	if config.MinifyHTML == nil {
+		minifyHTML := true
+		config.MinifyHTML = &minifyHTML
+	}
+	if config.CSP == "" {
+		config.CSP = defaultCSP
+	}
+	if err := config.Comments.validate(); err != nil {
+		slog.Warn("Invalid comments config, disabling comments", "err", err)
+		config.Comments = CommentsConfig{}
+	}
+	if config.Timezone == "" {
+		config.Timezone = defaultTimezone
+	} else if _, err := time.LoadLocation(config.Timezone); err != nil {
+		slog.Warn("Unknown timezone, using default", "timezone", config.Timezone, "default", defaultTimezone, "err", err)
+		config.Timezone = defaultTimezone
+	}
+	if config.RateLimit < 0 {
+		slog.Warn("Invalid rate_limit, using default", "rate_limit", config.RateLimit, "default", defaultRateLimit)
+	}
+	if config.RateLimit <= 0 {
+		config.RateLimit = defaultRateLimit
+	}
+	if config.RateBurst < 0 {
+		slog.Warn("Invalid rate_burst, using default", "rate_burst", config.RateBurst, "default", defaultRateBurst)
+	}
+	if config.RateBurst <= 0 {
+		config.RateBurst = defaultRateBurst
+	}
+	if config.MaxSearchResults < 0 {
+		slog.Warn("Invalid max_search_results, using default", "max_search_results", config.MaxSearchResults, "default", defaultMaxSearchResults)
+	}
+	if config.MaxSearchResults <= 0 {
+		config.MaxSearchResults = defaultMaxSearchResults
+	}
+	if config.WebSubHub != "" && !validHubURL(config.WebSubHub) {
+		slog.Warn("websub_hub must be an http(s) URL, ignoring it", "websub_hub", config.WebSubHub)
+		config.WebSubHub = ""
+	}
+	if config.TitleWeight < 0 {
+		slog.Warn("Invalid title_weight, using default", "title_weight", config.TitleWeight, "default", defaultTitleWeight)
+	}
+	if config.TitleWeight <= 0 {
+		config.TitleWeight = defaultTitleWeight
+	}
+	if config.BodyWeight < 0 {
+		slog.Warn("Invalid body_weight, using default", "body_weight", config.BodyWeight, "default", defaultBodyWeight)
+	}
+	if config.BodyWeight <= 0 {
+		config.BodyWeight = defaultBodyWeight
+	}
 	return config
 }
 
-func (b *Blog) LoadPosts() error {
-	entries, err := b.blogFS.ReadDir("blog")
-	if err != nil {
-		return fmt.Errorf("failed to read blog directory: %w", err)
-	}
+// LoadError reports a post file that could not be read or parsed.
+type LoadError struct {
+	Path string
+	Err  error
+}
 
-	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".md") {
-			continue
+func (e *LoadError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+// LoadPosts loads every .md and .markdown post under Config.ContentDir in
+// the blog FS and indexes them for search. Files that cannot be read or parsed, slug collisions and, with
+// StrictTags, unapproved tags are skipped; the good posts are still loaded
+// and the failures returned together, so callers decide whether a partial
+// load is acceptable. Read and parse failures are *LoadError values.
+func (b *Blog) LoadPosts() error {
+	var loadErrs []error
+	var tagErrs []error
+	var collisions []error
+	sources := make(map[string]string) // lowercased post ID -> file it came from
+	err := fs.WalkDir(b.blogFS, b.Config.ContentDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || postExt(entry.Name()) == "" {
+			return nil
 		}
 
-		path := "blog/" + entry.Name()
-		content, err := b.blogFS.ReadFile(path)
+		content, err := readPost(b.blogFS, path, b.Config.MaxPostSize)
+		if errors.Is(err, errPostTooLarge) {
+			slog.Warn("Skipping post: size exceeds limit", "path", path, "limit", b.Config.MaxPostSize)
+			return nil
+		}
 		if err != nil {
-			log.Printf("Error reading file %s: %v", path, err)
-			continue
+			loadErrs = append(loadErrs, &LoadError{Path: path, Err: err})
+			return nil
 		}
 
-		post, err := b.parsePost(entry.Name(), string(content))
+		relPath := strings.TrimPrefix(path, b.Config.ContentDir+"/")
+		post, err := b.parsePost(relPath, string(content))
 		if err != nil {
-			log.Printf("Error parsing post %s: %v", entry.Name(), err)
-			continue
+			loadErrs = append(loadErrs, &LoadError{Path: path, Err: err})
+			return nil
+		}
+
+		if post.Date.IsZero() && b.Config.PathDates {
+			if date, ok := dateFromPath(relPath, b.location); ok {
+				post.Date = date
+			}
+		}
+		if post.Updated.IsZero() {
+			post.Updated = post.Date
+		}
+
+		if err := b.checkTags(post); err != nil {
+			if b.Config.StrictTags {
+				tagErrs = append(tagErrs, fmt.Errorf("%s: %w", path, err))
+			} else {
+				slog.Warn("Unapproved tags", "path", path, "err", err)
+			}
+		}
+
+		// Slugs are compared case-insensitively because exported directories
+		// for "Post" and "post" would collide on case-insensitive filesystems.
+		key := strings.ToLower(post.ID)
+		if existing, ok := sources[key]; ok {
+			slog.Warn("Slug collision, skipping post", "slug", post.ID, "path", path, "existing", existing)
+			collisions = append(collisions, fmt.Errorf("slug %q: %s collides with %s", post.ID, path, existing))
+			return nil
+		}
+		sources[key] = path
+
+		// Drafts and scheduled posts stay out of listings, search and the
+		// export.
+		if post.Draft || b.scheduled(post) {
+			b.drafts[post.ID] = post
+			b.noteScheduled(post)
+			msg := "Draft post, not published"
+			if !post.Draft {
+				msg = "Scheduled post, not published before " + post.Date.Format("2006-01-02")
+			}
+			if link := b.PreviewURL(post.ID); link != "" {
+				slog.Info(msg, "path", path, "preview", link)
+			} else {
+				slog.Info(msg+"; set "+previewSecretEnv+" to preview it", "path", path)
+			}
+			return nil
 		}
 
 		b.posts[post.ID] = post
 		b.postList = append(b.postList, post)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read content directory: %w", err)
 	}
 
-	sort.Slice(b.postList, func(i, j int) bool {
-		return b.postList[i].Date.After(b.postList[j].Date)
+	sort.SliceStable(b.postList, func(i, j int) bool {
+		return postBefore(b.postList[i], b.postList[j])
 	})
 
-	b.buildInvertedIndex()
+	b.resolveInternalLinks(b.postList)
+
+	if !b.prebuiltIndex {
+		b.buildInvertedIndex()
+	} else {
+		b.indexTitles()
+	}
+	b.invalidateSearchIndex()
+	b.suggestions() // warm the autocomplete index before the first keystroke
+	return errors.Join(append(append(loadErrs, tagErrs...), collisions...)...)
+}
+
+// postBefore orders posts for listings: weighted posts first by ascending
+// weight, then everything else newest first. Without weights this is plain
+// date order.
+func postBefore(a, b *Post) bool {
+	if (a.Weight > 0) != (b.Weight > 0) {
+		return a.Weight > 0
+	}
+	if a.Weight != b.Weight {
+		return a.Weight < b.Weight
+	}
+	return a.Date.After(b.Date)
+}
+
+// checkTags reports tags that are not in Config.AllowedTags, compared as
+// normalizeTag does, so "Café" is allowed by "cafe". An empty allowlist
+// permits every tag.
+func (b *Blog) checkTags(post *Post) error {
+	if len(b.Config.AllowedTags) == 0 {
+		return nil
+	}
+
+	allowed := make(map[string]bool, len(b.Config.AllowedTags))
+	for _, tag := range b.Config.AllowedTags {
+		allowed[normalizeTag(tag)] = true
+	}
+	var unknown []string
+	for _, tag := range post.Tags {
+		if !allowed[normalizeTag(tag)] {
+			unknown = append(unknown, tag)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("tags not in allowed_tags: %s", strings.Join(unknown, ", "))
+	}
 	return nil
 }
 
-func (b *Blog) parsePost(filename, content string) (*Post, error) {
-	parts := strings.SplitN(content, "---", 3)
-	if len(parts) < 3 {
-		return nil, fmt.Errorf("invalid frontmatter")
+// postExtensions are the file extensions of markdown posts.
+var postExtensions = []string{".md", ".markdown"}
+
+// postExt returns the extension of the post file name, or "" when name is
+// not a post.
+func postExt(name string) string {
+	for _, ext := range postExtensions {
+		if strings.HasSuffix(name, ext) {
+			return ext
+		}
 	}
+	return ""
+}
 
-	frontmatter := parts[1]
-	markdownContent := strings.TrimSpace(parts[2])
+var pathDatePattern = regexp.MustCompile(`(?:^|/)(\d{4})/(\d{2})(?:/(\d{2}))?/`)
 
-	var title string
-	var date time.Time
-	var tags []string
-	for _, line := range strings.Split(frontmatter, "\n") {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "title:") {
-			title = strings.TrimSpace(strings.TrimPrefix(line, "title:"))
-		} else if strings.HasPrefix(line, "date:") {
-			dateStr := strings.TrimSpace(strings.TrimPrefix(line, "date:"))
-			var err error
-			date, err = time.Parse("2006-01-02", dateStr)
-			if err != nil {
-				date = time.Now()
-			}
-		} else if strings.HasPrefix(line, "tags:") {
-			tagsStr := strings.TrimSpace(strings.TrimPrefix(line, "tags:"))
-			tagList := strings.Split(tagsStr, ",")
-			for _, t := range tagList {
-				tags = append(tags, strings.TrimSpace(t))
-			}
+// dateFromPath infers a post date at midnight in loc from year/month[/day]
+// folders, as in 2023/05/01/title.md. A missing day defaults to the first
+// of the month.
+func dateFromPath(path string, loc *time.Location) (time.Time, bool) {
+	m := pathDatePattern.FindStringSubmatch(path)
+	if m == nil {
+		return time.Time{}, false
+	}
+	day := m[3]
+	if day == "" {
+		day = "01"
+	}
+	date, err := time.ParseInLocation("2006-01-02", m[1]+"-"+m[2]+"-"+day, loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+var (
+	errNoFrontmatter           = errors.New("no frontmatter: file must start with a ---, +++ or { line")
+	errUnterminatedFrontmatter = errors.New("unterminated frontmatter")
+	errPostTooLarge            = errors.New("post exceeds max_post_size")
+)
+
+// readPost reads the markdown file at path in fsys. It stops reading past
+// limit bytes, even when the file grew after it was listed, and returns
+// errPostTooLarge, so a runaway file cannot exhaust memory.
+func readPost(fsys fs.FS, path string, limit int64) ([]byte, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	content, err := io.ReadAll(io.LimitReader(f, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > limit {
+		return nil, errPostTooLarge
+	}
+	return content, nil
+}
+
+// splitFrontmatter decodes the frontmatter block, which must open on the
+// first line of content, and returns it with the markdown body. Later ---
+// lines belong to the body, where they are horizontal rules. Besides YAML
+// between --- lines it accepts Hugo's TOML between +++ lines and JSON
+// objects.
+func splitFrontmatter(content string) (fm frontmatter, body string, err error) {
+	content = strings.TrimPrefix(content, "\ufeff")
+	if strings.HasPrefix(content, "{") {
+		return splitJSONFrontmatter(content)
+	}
+	lines := strings.SplitAfter(content, "\n")
+	fence := strings.TrimRight(lines[0], "\r\n")
+	if fence != "---" && fence != "+++" {
+		return fm, "", errNoFrontmatter
+	}
+
+	offset := len(lines[0])
+	for _, line := range lines[1:] {
+		if strings.TrimRight(line, "\r\n") == fence {
+			fm, err = decodeFrontmatter(fence, content[len(lines[0]):offset])
+			return fm, content[offset+len(line):], err
+		}
+		offset += len(line)
+	}
+	return fm, "", fmt.Errorf("%w: missing closing %s line", errUnterminatedFrontmatter, fence)
+}
+
+// validSlugPattern matches URL-safe slugs accepted from frontmatter.
+var validSlugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+func (b *Blog) parsePost(filename, content string) (*Post, error) {
+	if int64(len(content)) > b.Config.MaxPostSize {
+		return nil, errPostTooLarge
+	}
+	fm, body, err := splitFrontmatter(content)
+	if err != nil {
+		return nil, err
+	}
+	markdownContent := strings.TrimSpace(body)
+
+	// Multi-line titles, such as YAML folded or TOML multi-line strings,
+	// are joined onto one line.
+	title := strings.Join(strings.Fields(fm.Title), " ")
+	var date, updated time.Time
+	if fm.Date != "" {
+		if date, err = parseFrontmatterDate(string(fm.Date), b.location); err != nil {
+			date = time.Now().In(b.location)
 		}
 	}
+	if fm.Updated != "" {
+		if t, err := parseFrontmatterDate(string(fm.Updated), b.location); err == nil {
+			updated = t
+		}
+	}
+	tags := cleanTags(fm.Tags)
+	ogType := "article"
+	if fm.OGType != "" {
+		ogType = fm.OGType
+	}
+	featured, featuredOrder := fm.Featured, 0
+	if fm.FeaturedOrder != "" {
+		// Giving an order implies featured.
+		if n, err := strconv.Atoi(string(fm.FeaturedOrder)); err == nil {
+			featured, featuredOrder = true, n
+		} else {
+			slog.Warn("Invalid featured_order, ignoring", "path", filename, "featured_order", fm.FeaturedOrder)
+		}
+	}
+	weight := 0
+	if fm.Weight != "" {
+		if n, err := strconv.Atoi(string(fm.Weight)); err == nil && n > 0 {
+			weight = n
+		} else {
+			slog.Warn("Weight must be a positive integer, ignoring", "path", filename, "weight", fm.Weight)
+		}
+	}
+	slugOverride, image := strings.TrimSpace(fm.Slug), strings.TrimSpace(fm.Image)
+
+	source := []byte(markdownContent)
+	doc := b.markdown.Parser().Parse(text.NewReader(source))
 
-	var buf bytes.Buffer
-	if err := b.markdown.Convert([]byte(markdownContent), &buf); err != nil {
+	var html template.HTML
+	if r, ok := b.renderer.(goldmarkRenderer); ok {
+		// The document is parsed for its TOC anyway, so render it directly.
+		html, err = r.renderDoc(source, doc)
+	} else {
+		html, err = b.renderer.Render(markdownContent)
+	}
+	if err != nil {
 		return nil, fmt.Errorf("failed to convert markdown: %w", err)
 	}
 
-	slug := strings.TrimSuffix(filename, ".md")
+	if updated.IsZero() {
+		updated = date
+	}
+
+	// Posts in subdirectories get the directories folded into the slug,
+	// so blog/2024/hello.md becomes 2024-hello.
+	slug := strings.ReplaceAll(strings.TrimSuffix(filename, postExt(filename)), "/", "-")
+	if slugOverride != "" {
+		if validSlugPattern.MatchString(slugOverride) {
+			slug = slugOverride
+		} else {
+			slog.Warn("Slug must contain only lowercase letters, digits and hyphens; using filename slug", "path", filename, "slug", slugOverride, "fallback", slug)
+		}
+	}
+	if title == "" {
+		title = titleFromSlug(slug)
+		slog.Warn("Post has no title, deriving one from its slug", "path", filename, "title", title)
+	}
+	if image == "" && b.Config.OGImages {
+		image = ogImagePath(slug)
+	}
 
 	return &Post{
-		ID:          slug,
-		Title:       title,
-		Date:        date,
-		Tags:        tags,
-		Content:     markdownContent,
-		HTMLContent: template.HTML(buf.String()),
-		Slug:        slug,
+		ID:            slug,
+		Title:         title,
+		Date:          date,
+		Updated:       updated,
+		Tags:          tags,
+		Content:       markdownContent,
+		HTMLContent:   html,
+		Slug:          slug,
+		OGType:        ogType,
+		TOC:           tableOfContents(doc, source),
+		ReadingTime:   readingTime(markdownContent, b.Config.ReadingWPM),
+		Series:        fm.Series,
+		Excerpt:       excerpt(plainText(markdownContent), excerptLength),
+		HasMath:       hasMath(doc, source),
+		Weight:        weight,
+		Image:         image,
+		Category:      fm.Category,
+		Draft:         fm.Draft,
+		Featured:      featured,
+		FeaturedOrder: featuredOrder,
 	}, nil
 }
 
+// titleFromSlug turns a slug such as my-first-post into the fallback title
+// "My First Post" for posts without a title.
+func titleFromSlug(slug string) string {
+	words := strings.FieldsFunc(slug, func(r rune) bool { return r == '-' || r == '_' })
+	for i, word := range words {
+		r, size := utf8.DecodeRuneInString(word)
+		words[i] = string(unicode.ToUpper(r)) + word[size:]
+	}
+	return strings.Join(words, " ")
+}
+
+// cleanTags trims whitespace and quotes from tags, collapses the spaces
+// inside them, and drops empty ones.
+func cleanTags(items []string) []string {
+	var tags []string
+	for _, item := range items {
+		tag := strings.Join(strings.Fields(strings.Trim(strings.TrimSpace(item), `"'`)), " ")
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// readingTime estimates the minutes needed to read content at wpm words per
+// minute, rounding up and never returning less than one minute.
+func readingTime(content string, wpm int) int {
+	words := len(strings.Fields(content))
+	minutes := (words + wpm - 1) / wpm
+	if minutes < 1 {
+		return 1
+	}
+	return minutes
+}
+
 func (b *Blog) buildInvertedIndex() {
 	b.invertedIndex.mu.Lock()
 	defer b.invertedIndex.mu.Unlock()
 
 	b.invertedIndex.index = make(map[string][]string)
+	b.invertedIndex.titles = make(map[string][]string)
+	b.invertedIndex.terms = make(map[string][]string)
 
 	for _, post := range b.posts {
-		words := tokenize(post.Title + " " + post.Content)
-		for _, word := range words {
-			word = strings.ToLower(word)
-			if !contains(b.invertedIndex.index[word], post.ID) {
-				b.invertedIndex.index[word] = append(b.invertedIndex.index[word], post.ID)
-			}
-		}
+		b.invertedIndex.add(post)
 	}
 }
 
+// maxWordLength is the longest word tokenize keeps, in bytes. Longer runs
+// of letters, such as base64 blobs, are never searched for and would only
+// grow the index.
+const maxWordLength = 100
+
+// tokenize splits text into runs of letters and digits in any script.
+// Combining marks stay part of the word so decomposed accents do not split
+// it. Words longer than maxWordLength are dropped. Tokens keep their case;
+// compare them through foldCase.
 func tokenize(text string) []string {
-	re := regexp.MustCompile(`[a-zA-Z0-9]+`)
-	return re.FindAllString(text, -1)
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r)
+	})
+	kept := words[:0]
+	for _, word := range words {
+		if len(word) <= maxWordLength {
+			kept = append(kept, word)
+		}
+	}
+	return kept
+}
+
+// foldCase lowercases s for index and query comparison. Turkish dotted
+// capital I is folded to a plain i rather than the i plus combining dot
+// that strings.ToLower produces, so "İstanbul" matches "istanbul".
+func foldCase(s string) string {
+	return strings.ToLower(strings.ReplaceAll(s, "İ", "I"))
 }
 
 func contains(slice []string, item string) bool {
@@ -260,138 +958,452 @@ func contains(slice []string, item string) bool {
 	return false
 }
 
-func (b *Blog) Export(distDir string) {
-	os.RemoveAll(distDir)
-	os.MkdirAll(distDir, 0755)
+func (b *Blog) homeData() map[string]interface{} {
+	featured, posts := b.featuredPosts()
+	return map[string]interface{}{
+		"Title":      "Home",
+		"Featured":   featured,
+		"Posts":      posts,
+		"Categories": b.categories(),
+		"Config":     b.Config,
+		"Theme":      b.Config.DefaultTheme,
+	}
+}
 
-	exportHTML := func(filename string, templateName string, data interface{}) {
-		var buf bytes.Buffer
-		_ = b.templates.ExecuteTemplate(&buf, templateName, data)
-		minified, _ := b.minifier.Bytes("text/html", buf.Bytes())
-		_ = os.WriteFile(filepath.Join(distDir, filename), minified, 0644)
+// featuredPosts splits the posts into the featured ones, ordered by
+// featured_order (unordered ones last, newest first), and the rest.
+func (b *Blog) featuredPosts() (featured, rest []*Post) {
+	rest = make([]*Post, 0, len(b.postList))
+	for _, post := range b.postList {
+		if post.Featured {
+			featured = append(featured, post)
+		} else {
+			rest = append(rest, post)
+		}
 	}
+	sort.SliceStable(featured, func(i, j int) bool {
+		oi, oj := featured[i].FeaturedOrder, featured[j].FeaturedOrder
+		if (oi == 0) != (oj == 0) {
+			return oj == 0
+		}
+		return oi < oj
+	})
+	return featured, rest
+}
 
-	// Export Home
-	data := map[string]interface{}{
-		"Title":      "Home",
-		"Posts":      b.postList,
-		"Config":     b.Config,
-		"StaticMode": true,
+// searchData builds the search page for the given 1-based page of results,
+// Config.PostsPerPage at a time. Out-of-range pages are clamped.
+func (b *Blog) searchData(query string, page int) map[string]interface{} {
+	posts, broadened, cached := b.cachedSearch(query)
+
+	total := len(posts)
+	totalPages := (total + b.Config.PostsPerPage - 1) / b.Config.PostsPerPage
+	page = max(1, min(page, totalPages))
+	start := min((page-1)*b.Config.PostsPerPage, total)
+	end := min(start+b.Config.PostsPerPage, total)
+	prevPage, nextPage := 0, 0
+	if page > 1 {
+		prevPage = page - 1
+	}
+	if page < totalPages {
+		nextPage = page + 1
 	}
-	exportHTML("index.html", "index.html", data)
 
-	// Export Search Page
-	os.MkdirAll(filepath.Join(distDir, "search"), 0755)
-	searchData := map[string]interface{}{
-		"Title":      "Search Results",
-		"Query":      "",
-		"Posts":      nil,
-		"Config":     b.Config,
-		"StaticMode": true,
+	// Searching for a tag lists that tag's posts, so suggest its neighbors.
+	var relatedTags []string
+	if q := strings.TrimSpace(query); q != "" {
+		relatedTags = b.coOccurringTags(q, relatedTagsCount)
 	}
-	exportHTML("search/index.html", "search.html", searchData)
 
-	// Export Posts
-	os.MkdirAll(filepath.Join(distDir, "post"), 0755)
-	for slug, post := range b.posts {
-		os.MkdirAll(filepath.Join(distDir, "post", slug), 0755)
-		postData := map[string]interface{}{
-			"Title":      post.Title,
-			"Post":       post,
-			"Config":     b.Config,
-			"StaticMode": true,
+	return map[string]interface{}{
+		"Title":        "Search Results",
+		"Query":        query,
+		"Searched":     strings.TrimSpace(query) != "",
+		"Posts":        posts[start:end],
+		"CacheHit":     cached,
+		"CurrentPage":  page,
+		"TotalResults": total,
+		"TotalPages":   totalPages,
+		"PrevPage":     prevPage,
+		"NextPage":     nextPage,
+		"Broadened":    broadened,
+		"RelatedTags":  relatedTags,
+		"Config":       b.Config,
+		"Theme":        b.Config.DefaultTheme,
+	}
+}
+
+func (b *Blog) postData(post *Post) map[string]interface{} {
+	series := b.seriesPosts(post.Series)
+	seriesPart := 0
+	for i, p := range series {
+		if p.ID == post.ID {
+			seriesPart = i + 1
 		}
-		exportHTML("post/"+slug+"/index.html", "post.html", postData)
 	}
 
-	// Export Static Files
-	os.MkdirAll(filepath.Join(distDir, "static"), 0755)
-	entries, _ := b.staticFS.ReadDir("static")
-	for _, entry := range entries {
-		path := "static/" + entry.Name()
-		data, _ := b.staticFS.ReadFile(path)
+	return map[string]interface{}{
+		"Title":      post.Title,
+		"Post":       post,
+		"TOC":        post.TOC,
+		"Series":     series,
+		"SeriesPart": seriesPart,
+		"JSONLD":     b.postJSONLD(post),
+		"OGImage":    b.ogImageURL(post),
+		"Config":     b.Config,
+		"Theme":      b.Config.DefaultTheme,
+		"Comments":   b.commentsData(post),
+	}
+}
 
-		var minified []byte
-		ext := filepath.Ext(entry.Name())
-		switch ext {
-		case ".css":
-			minified, _ = b.minifier.Bytes("text/css", data)
-		case ".js":
-			minified, _ = b.minifier.Bytes("text/javascript", data)
-		default:
-			minified = data
-		}
-		os.WriteFile(filepath.Join(distDir, "static", entry.Name()), minified, 0644)
+// seriesPosts returns the posts in the named series in reading order,
+// oldest first. An empty name matches nothing.
+func (b *Blog) seriesPosts(name string) []*Post {
+	if name == "" {
+		return nil
 	}
 
-	// Export Search Index
-	var indexPosts []struct {
-		ID    string   `json:"id"`
-		Title string   `json:"title"`
-		Date  string   `json:"date"`
-		Tags  []string `json:"tags"`
-		Slug  string   `json:"slug"`
+	var posts []*Post
+	for _, post := range b.postList {
+		if post.Series == name {
+			posts = append(posts, post)
+		}
 	}
+	sort.SliceStable(posts, func(i, j int) bool {
+		return posts[i].Date.Before(posts[j].Date)
+	})
+	return posts
+}
+
+// NewSearchIndex snapshots the posts and inverted index into the structure
+// served as search-index.json.
+func (b *Blog) NewSearchIndex() SearchIndex {
+	var indexPosts []SearchIndexPost
 	for _, post := range b.postList {
-		tags := post.Tags
-		if tags == nil {
-			tags = []string{}
-		}
-		indexPosts = append(indexPosts, struct {
-			ID    string   `json:"id"`
-			Title string   `json:"title"`
-			Date  string   `json:"date"`
-			Tags  []string `json:"tags"`
-			Slug  string   `json:"slug"`
-		}{
-			ID:    post.ID,
-			Title: post.Title,
-			Date:  post.Date.Format("2006-01-02"),
-			Tags:  tags,
-			Slug:  post.Slug,
-		})
+		indexPosts = append(indexPosts, newSearchIndexPost(post))
 	}
 
+	// Posting lists are sorted and encoding/json sorts the words, so the
+	// same posts always produce the same JSON. The lists are copied so
+	// later index updates cannot change them under the caller.
 	b.invertedIndex.mu.RLock()
-	invertedIndex := make(map[string][]string)
+	invertedIndex := make(map[string][]string, len(b.invertedIndex.index))
 	for word, ids := range b.invertedIndex.index {
-		invertedIndex[word] = ids
+		invertedIndex[word] = append([]string(nil), ids...)
 	}
 	b.invertedIndex.mu.RUnlock()
 
-	searchIndex := map[string]interface{}{
-		"posts":         indexPosts,
-		"invertedIndex": invertedIndex,
+	return SearchIndex{
+		Posts:         indexPosts,
+		InvertedIndex: invertedIndex,
+		InfixSearch:   b.Config.InfixSearch,
 	}
+}
 
-	jsonData, _ := json.Marshal(searchIndex) // Minified JSON
-	os.WriteFile(filepath.Join(distDir, "search-index.json"), jsonData, 0644)
+// exportMarker is written into every export so a later Export knows the
+// directory is safe to wipe.
+const exportMarker = ".blog-export"
 
-	// Generate robots.txt
-	robotsContent := fmt.Sprintf("User-agent: *\nAllow: /\nSitemap: %s/sitemap.xml\n", b.Config.BaseURL)
-	os.WriteFile(filepath.Join(distDir, "robots.txt"), []byte(robotsContent), 0644)
+// checkExportDir refuses to let Export wipe distDir unless it is missing,
+// empty, or holds our marker file. An index.html alone could be anyone's
+// site, so exports made before the marker existed are only replaced when
+// force is set.
+func checkExportDir(distDir string, force bool) error {
+	entries, err := os.ReadDir(distDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read output directory: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	legacy := false
+	for _, entry := range entries {
+		switch entry.Name() {
+		case exportMarker:
+			return nil
+		case "index.html":
+			legacy = true
+		}
+	}
+	if legacy {
+		if force {
+			return nil
+		}
+		return fmt.Errorf("refusing to overwrite %s: it has no %s marker; if it is an older export, export with force to replace it", distDir, exportMarker)
+	}
+	return fmt.Errorf("refusing to overwrite %s: it is not empty and does not look like a previous export", distDir)
+}
 
-	// Generate sitemap.xml
-	var sitemap bytes.Buffer
-	sitemap.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
-	sitemap.WriteString("\n")
-	sitemap.WriteString(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
-	sitemap.WriteString("\n")
+// exportSink receives the files of an export one at a time: write streams
+// the contents of the file name into the writer it is given. Posts are
+// exported concurrently, so sinks must be safe for concurrent use.
+type exportSink func(name string, write func(io.Writer) error) error
 
-	// Home page
-	sitemap.WriteString(fmt.Sprintf("\t<url><loc>%s/</loc><changefreq>weekly</changefreq><priority>1.0</priority></url>\n", b.Config.BaseURL))
+// exportWorkers bounds how many posts are rendered at once.
+var exportWorkers = runtime.GOMAXPROCS(0)
 
-	// Search page
-	sitemap.WriteString(fmt.Sprintf("\t<url><loc>%s/search/</loc><changefreq>monthly</changefreq><priority>0.3</priority></url>\n", b.Config.BaseURL))
+// Export writes the static site to distDir, updating a previous export.
+// It returns an error, leaving the directory untouched, when distDir holds
+// anything else. Pages are rendered straight into their files, so memory
+// use does not grow with the size of the site.
+func (b *Blog) Export(distDir string) error {
+	_, err := b.ExportIncremental(distDir, false)
+	return err
+}
 
-	// Posts
-	for _, post := range b.postList {
-		sitemap.WriteString(fmt.Sprintf("\t<url><loc>%s/post/%s/</loc><lastmod>%s</lastmod><changefreq>monthly</changefreq><priority>0.8</priority></url>\n",
-			b.Config.BaseURL, post.Slug, post.Date.Format("2006-01-02")))
+// ExportIncremental is like Export but reports what it did. Files whose
+// contents match the hash the previous export recorded are not rewritten,
+// so hosts and CDNs see only what changed; force rewrites every file.
+func (b *Blog) ExportIncremental(distDir string, force bool) (ExportReport, error) {
+	var report ExportReport
+	if err := checkExportDir(distDir, force); err != nil {
+		return report, err
+	}
+
+	previous, ok := readExportManifest(distDir, b.Config.Precompress)
+	if force || !ok {
+		if err := os.RemoveAll(distDir); err != nil {
+			return report, fmt.Errorf("failed to clear output directory: %w", err)
+		}
+		previous = exportManifest{}
+	}
+	if err := os.MkdirAll(distDir, 0755); err != nil {
+		return report, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if previous.Files == nil {
+		if err := os.WriteFile(filepath.Join(distDir, exportMarker), nil, 0644); err != nil {
+			return report, fmt.Errorf("failed to write export marker: %w", err)
+		}
+	}
+
+	current := exportManifest{Precompress: b.Config.Precompress, Files: make(map[string]string)}
+	var mu sync.Mutex
+	record := func(name, sum string, written bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		current.Files[name] = sum
+		if written {
+			report.Written = append(report.Written, name)
+		} else {
+			report.Skipped = append(report.Skipped, name)
+		}
+	}
+
+	err := b.export(func(name string, write func(io.Writer) error) error {
+		path := filepath.Join(distDir, filepath.FromSlash(name))
+		// Files the previous export wrote are rendered once just to hash
+		// them, and only rendered into the file when they changed.
+		if previousSum, ok := previous.Files[name]; ok {
+			h := sha256.New()
+			if err := write(h); err != nil {
+				return fmt.Errorf("failed to write %s: %w", name, err)
+			}
+			if sum := hex.EncodeToString(h.Sum(nil)); sum == previousSum {
+				if _, err := os.Stat(path); err == nil {
+					record(name, sum, false)
+					return nil
+				}
+			}
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+		precompress := b.Config.Precompress && precompressedExts[filepath.Ext(name)]
+		h := sha256.New()
+		err := writeExportFile(path, precompress, func(w io.Writer) error {
+			return write(io.MultiWriter(w, h))
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		record(name, hex.EncodeToString(h.Sum(nil)), true)
+		return nil
+	})
+	report.Removed = removeStaleFiles(distDir, current.Files)
+	report.sort()
+	// Files that failed are left out, so the next export rewrites them.
+	if manifestErr := writeExportManifest(distDir, current); manifestErr != nil {
+		err = errors.Join(err, fmt.Errorf("failed to write export manifest: %w", manifestErr))
+	}
+	if err != nil {
+		return report, err
+	}
+
+	slog.Info("Generated optimized static site with SEO assets", "dir", distDir,
+		"written", len(report.Written), "skipped", len(report.Skipped), "removed", len(report.Removed))
+	return report, nil
+}
+
+// ExportFiles renders the static site in memory, mapping slash-separated
+// paths such as post/hello/index.html to their contents. Export writes
+// these files to disk; they can also be served directly where the
+// filesystem is read-only.
+func (b *Blog) ExportFiles() map[string][]byte {
+	files := make(map[string][]byte)
+	if err := b.export(memorySink(files)); err != nil {
+		slog.Error("Error exporting files", "err", err)
+	}
+	return files
+}
+
+// memorySink collects exported files into files, keeping whatever was
+// written of a file that failed.
+func memorySink(files map[string][]byte) exportSink {
+	var mu sync.Mutex
+	return func(name string, write func(io.Writer) error) error {
+		var buf bytes.Buffer
+		err := write(&buf)
+		mu.Lock()
+		files[name] = buf.Bytes()
+		mu.Unlock()
+		return err
+	}
+}
+
+// writeBytes is the write function of a file rendered ahead of time.
+func writeBytes(data []byte) func(io.Writer) error {
+	return func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	}
+}
+
+// encodeJSON encodes v compactly, without building the whole document in
+// a byte slice of its own first.
+func encodeJSON(v interface{}) func(io.Writer) error {
+	return func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(v)
+	}
+}
+
+// export renders every file of the static site into sink. It keeps going
+// after a file fails and returns all the failures.
+func (b *Blog) export(sink exportSink) error {
+	var errs []error
+	var errsMu sync.Mutex
+	check := func(err error) {
+		if err != nil {
+			errsMu.Lock()
+			errs = append(errs, err)
+			errsMu.Unlock()
+		}
+	}
+
+	exportHTML := func(filename string, templateName string, data interface{}) {
+		check(sink(filename, func(w io.Writer) error {
+			if !*b.Config.MinifyHTML {
+				return b.templates.ExecuteTemplate(w, templateName, data)
+			}
+			// The HTML minifier keeps <pre> contents, so code blocks are untouched.
+			mw := b.minifier.Writer("text/html", w)
+			err := b.templates.ExecuteTemplate(mw, templateName, data)
+			if closeErr := mw.Close(); err == nil {
+				err = closeErr
+			}
+			return err
+		}))
+	}
+
+	// Export Static Files first so pages can reference their hashed names
+	assets, err := b.exportStaticAssets(sink)
+	check(err)
+
+	// Export Home
+	data := b.homeData()
+	data["StaticMode"] = true
+	data["Assets"] = assets
+	exportHTML("index.html", "index.html", data)
+
+	// Export Search Page
+	searchData := b.searchData("", 1)
+	searchData["StaticMode"] = true
+	searchData["Assets"] = assets
+	exportHTML("search/index.html", "search.html", searchData)
+
+	// Export Archive
+	archiveData := b.archiveData()
+	archiveData["StaticMode"] = true
+	archiveData["Assets"] = assets
+	exportHTML("archive/index.html", "archive.html", archiveData)
+
+	// Export Categories
+	categoryData, _ := b.categoryData("")
+	categoryData["StaticMode"] = true
+	categoryData["Assets"] = assets
+	exportHTML("category/index.html", "category.html", categoryData)
+	for _, category := range b.categories() {
+		categoryData, _ := b.categoryData(category.Slug)
+		categoryData["StaticMode"] = true
+		categoryData["Assets"] = assets
+		exportHTML("category/"+category.Slug+"/index.html", "category.html", categoryData)
+	}
+
+	// Export Posts, exportWorkers at a time
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, exportWorkers)
+	for slug, post := range b.posts {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func() {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+
+			postData := b.postData(post)
+			postData["StaticMode"] = true
+			postData["Assets"] = assets
+			exportHTML("post/"+slug+"/index.html", "post.html", postData)
+
+			if b.Config.AMP {
+				exportHTML("post/"+slug+"/amp/index.html", "amp.html", b.ampData(post))
+			}
+
+			if b.Config.OGImages && post.Image == ogImagePath(slug) {
+				if data, err := renderOGImage(post.Title, b.Config.BlogName, slug); err == nil {
+					check(sink(strings.TrimPrefix(post.Image, "/"), writeBytes(data)))
+				} else {
+					slog.Error("Error generating preview image", "slug", slug, "err", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Export the 404 page static hosts serve for unknown paths
+	notFoundData := b.notFoundData("")
+	notFoundData["StaticMode"] = true
+	notFoundData["Assets"] = assets
+	exportHTML("404.html", "404.html", notFoundData)
+
+	// Export pages redirecting moved posts
+	for slug, target := range b.redirects {
+		if _, ok := b.posts[slug]; !ok {
+			check(sink("post/"+slug+"/index.html", writeBytes(b.redirectHTML(target))))
+		}
 	}
 
-	sitemap.WriteString(`</urlset>`)
-	os.WriteFile(filepath.Join(distDir, "sitemap.xml"), sitemap.Bytes(), 0644)
+	// Export Search Index
+	check(sink("search-index.json", encodeJSON(b.NewSearchIndex())))
+	check(sink("slugs.json", encodeJSON(b.slugList())))
+	check(sink("tags.json", encodeJSON(b.tagList())))
+
+	// Generate robots.txt and sitemap.xml
+	check(sink("robots.txt", writeBytes(b.robotsTxt())))
+	check(sink("sitemap.xml", writeBytes(b.sitemapXML())))
+
+	// Generate feeds
+	check(sink("rss.xml", writeBytes(b.rssXML())))
+	check(sink("atom.xml", writeBytes(b.atomXML())))
+	check(sink("feed.json", encodeJSON(b.jsonFeed())))
+
+	// Generate the web app manifest and service worker
+	check(sink("manifest.webmanifest", encodeJSON(b.webManifest(assets))))
+	check(sink("sw.js", writeBytes(b.serviceWorkerJS(assets))))
 
-	fmt.Printf("Successfully generated optimized static site with SEO assets in ./%s\n", distDir)
+	return errors.Join(errs...)
 }
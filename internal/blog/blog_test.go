@@ -1,12 +1,40 @@
 package blog
 
 import (
+	"bytes"
 	"embed"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
 	"strings"
 	"testing"
+	"testing/fstest"
 	"time"
 )
 
+// newTestBlog builds a blog using the repository's templates and static
+// assets, with posts loaded from the given in-memory files keyed by path
+// relative to the blog directory.
+func newTestBlog(t testing.TB, files map[string]string) *Blog {
+	t.Helper()
+	root := os.DirFS("../..")
+	blog, _ := NewBlog(root, root, embed.FS{})
+	mapFS := fstest.MapFS{}
+	for name, content := range files {
+		mapFS["blog/"+name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	blog.blogFS = mapFS
+	if err := blog.LoadPosts(); err != nil {
+		t.Fatalf("Failed to load posts: %v", err)
+	}
+	return blog
+}
+
 func TestParsePost(t *testing.T) {
 	blog, _ := NewBlog(embed.FS{}, embed.FS{}, embed.FS{})
 	content := `---
@@ -59,3 +87,898 @@ func TestTokenize(t *testing.T) {
 		}
 	}
 }
+
+func TestTokenizeUnicode(t *testing.T) {
+	tests := []struct {
+		text   string
+		tokens []string
+		folded []string
+	}{
+		{"café au lait", []string{"café", "au", "lait"}, []string{"café", "au", "lait"}},
+		{"Ωmega-3", []string{"Ωmega", "3"}, []string{"ωmega", "3"}},
+		{"İstanbul'da", []string{"İstanbul", "da"}, []string{"istanbul", "da"}},
+		{"cafe\u0301", []string{"cafe\u0301"}, []string{"cafe\u0301"}},
+	}
+
+	for _, tt := range tests {
+		tokens := tokenize(tt.text)
+		if !reflect.DeepEqual(tokens, tt.tokens) {
+			t.Errorf("tokenize(%q) = %q, want %q", tt.text, tokens, tt.tokens)
+			continue
+		}
+		for i, token := range tokens {
+			if got := foldCase(token); got != tt.folded[i] {
+				t.Errorf("foldCase(%q) = %q, want %q", token, got, tt.folded[i])
+			}
+		}
+	}
+}
+
+func TestSearchNonASCII(t *testing.T) {
+	b := newTestBlog(t, map[string]string{
+		"cafe.md":      "---\ntitle: Café Culture\ndate: 2024-01-01\n---\nNotes from İstanbul.",
+		"unrelated.md": "---\ntitle: Unrelated\ndate: 2024-01-02\n---\nNothing to see.",
+	})
+
+	for _, query := range []string{"café", "CAFÉ", "istanbul", "İSTANBUL"} {
+		results := b.search(query)
+		if len(results) != 1 || results[0].ID != "cafe" {
+			t.Errorf("search(%q) = %v, want only cafe", query, results)
+		}
+	}
+}
+
+func TestParseConfigEnvironmentOverride(t *testing.T) {
+	data := []byte(`
+blog_name: Test Blog
+base_url: https://example.com
+environments:
+  production:
+    base_url: https://prod.example.com
+  staging:
+    base_url: https://staging.example.com
+`)
+
+	t.Setenv("APP_ENV", "production")
+	config, err := parseConfig(data, os.Getenv("APP_ENV"))
+	if err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+	if config.BaseURL != "https://prod.example.com" {
+		t.Errorf("Expected production base URL, got '%s'", config.BaseURL)
+	}
+	if config.BlogName != "Test Blog" {
+		t.Errorf("Expected base blog name to be kept, got '%s'", config.BlogName)
+	}
+
+	config, err = parseConfig(data, "unknown")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+	if config.BaseURL != "https://example.com" {
+		t.Errorf("Expected base URL for unknown environment, got '%s'", config.BaseURL)
+	}
+}
+
+func TestLoadPostsSkipsOversizedFiles(t *testing.T) {
+	blog, _ := NewBlog(embed.FS{}, embed.FS{}, embed.FS{})
+	blog.Config.MaxPostSize = 128
+	blog.blogFS = fstest.MapFS{
+		"blog/small.md": {Data: []byte("---\ntitle: Small\ndate: 2024-01-01\n---\nShort post.")},
+		"blog/huge.md":  {Data: []byte("---\ntitle: Huge\ndate: 2024-01-02\n---\n" + strings.Repeat("word ", 100))},
+		// Exactly at the limit, which is allowed.
+		"blog/exact.md": {Data: []byte("---\ntitle: Exact\ndate: 2024-01-03\n---\n" + strings.Repeat("x", 128-38))},
+	}
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	if err := blog.LoadPosts(); err != nil {
+		t.Fatalf("Failed to load posts: %v", err)
+	}
+
+	if _, ok := blog.posts["huge"]; ok {
+		t.Errorf("Expected oversized post to be skipped")
+	}
+	if _, ok := blog.posts["small"]; !ok {
+		t.Errorf("Expected small post to be loaded")
+	}
+	if _, ok := blog.posts["exact"]; !ok {
+		t.Errorf("Expected a post exactly at the limit to be loaded")
+	}
+	if !strings.Contains(logs.String(), "blog/huge.md") || !strings.Contains(logs.String(), "exceeds limit") {
+		t.Errorf("Expected a warning about blog/huge.md, got %q", logs.String())
+	}
+}
+
+func TestPostSizeLimits(t *testing.T) {
+	blog, _ := NewBlogWithConfig(Config{MaxPostSize: 64}, embed.FS{}, embed.FS{}, embed.FS{})
+	if err := blog.AddMarkdown("big.md", "---\ntitle: Big\ndate: 2024-01-01\n---\n"+strings.Repeat("x", 64)); !errors.Is(err, errPostTooLarge) {
+		t.Errorf("Expected an added post over the limit to be rejected, got %v", err)
+	}
+
+	// Overlong words and queries don't grow the index or the search.
+	blob := strings.Repeat("a", maxWordLength+1)
+	if words := tokenize("short " + blob + " " + blob[1:]); !reflect.DeepEqual(words, []string{"short", blob[1:]}) {
+		t.Errorf("Expected words over %d bytes to be dropped, got %d words", maxWordLength, len(words))
+	}
+	blog = newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-01\n---\nHello gophers.",
+	})
+	query := "hello " + strings.Repeat("gophers ", maxQueryWords-1) + "missing"
+	if results := blog.search(query); len(results) != 1 {
+		t.Errorf("Expected words past the first %d of a query to be ignored, got %v", maxQueryWords, results)
+	}
+}
+
+func TestParsePostOGType(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"talk.md":  "---\ntitle: My Talk\ndate: 2024-01-27\nog_type: video\n---\nRecording.",
+		"notes.md": "---\ntitle: Notes\ndate: 2024-01-28\n---\nText.",
+	})
+
+	data := blog.postData(blog.posts["talk"])
+	if post := data["Post"].(*Post); post.OGType != "video" {
+		t.Errorf("Expected og_type 'video', got '%s'", post.OGType)
+	}
+	if blog.posts["notes"].OGType != "article" {
+		t.Errorf("Expected default og_type 'article', got '%s'", blog.posts["notes"].OGType)
+	}
+}
+
+func TestLoadPostsPathDates(t *testing.T) {
+	blog, _ := NewBlog(embed.FS{}, embed.FS{}, embed.FS{})
+	blog.Config.PathDates = true
+	blog.blogFS = fstest.MapFS{
+		"blog/2023/05/01/undated.md": {Data: []byte("---\ntitle: Undated\n---\nBody")},
+		"blog/2023/05/02/dated.md":   {Data: []byte("---\ntitle: Dated\ndate: 2024-02-03\n---\nBody")},
+	}
+	if err := blog.LoadPosts(); err != nil {
+		t.Fatalf("Failed to load posts: %v", err)
+	}
+
+	undated, ok := blog.posts["2023-05-01-undated"]
+	if !ok {
+		t.Fatalf("Expected nested post to be loaded")
+	}
+	if want, _ := time.Parse("2006-01-02", "2023-05-01"); !undated.Date.Equal(want) {
+		t.Errorf("Expected path date %v, got %v", want, undated.Date)
+	}
+
+	if want, _ := time.Parse("2006-01-02", "2024-02-03"); !blog.posts["2023-05-02-dated"].Date.Equal(want) {
+		t.Errorf("Expected frontmatter date %v to win, got %v", want, blog.posts["2023-05-02-dated"].Date)
+	}
+}
+
+func TestCodeStyleConfig(t *testing.T) {
+	content := "---\ntitle: Code\ndate: 2024-01-27\n---\n```go\nfunc main() {}\n```"
+
+	render := func(style string) string {
+		t.Helper()
+		blog, _ := NewBlogWithConfig(Config{CodeStyle: style}, embed.FS{}, embed.FS{}, embed.FS{})
+		post, err := blog.parsePost("code.md", content)
+		if err != nil {
+			t.Fatalf("Failed to parse post: %v", err)
+		}
+		return string(post.HTMLContent)
+	}
+
+	monokai := render("")
+	if !strings.Contains(monokai, "#272822") {
+		t.Errorf("Expected default monokai background in %s", monokai)
+	}
+
+	github := render("github")
+	if strings.Contains(github, "#272822") || !strings.Contains(github, "background-color:") {
+		t.Errorf("Expected github-styled code block, got %s", github)
+	}
+
+	if unknown := render("no-such-style"); unknown != monokai {
+		t.Errorf("Expected unknown style to fall back to monokai")
+	}
+}
+
+func TestCodeLineNumbers(t *testing.T) {
+	content := "---\ntitle: Code\ndate: 2024-01-27\n---\n```go\npackage main\n\nfunc main() {}\n```"
+
+	blog, _ := NewBlogWithConfig(Config{CodeLineNumbers: true}, embed.FS{}, embed.FS{}, embed.FS{})
+	post, err := blog.parsePost("code.md", content)
+	if err != nil {
+		t.Fatalf("Failed to parse post: %v", err)
+	}
+	html := string(post.HTMLContent)
+
+	if !strings.Contains(html, `<div class="code-block" data-lang="go">`) {
+		t.Errorf("Expected code block wrapper with data-lang, got %s", html)
+	}
+	// Line numbers are excluded from selection so copied code stays clean.
+	if !strings.Contains(html, "user-select:none") || !strings.Contains(html, ">3</span>") {
+		t.Errorf("Expected line number markup, got %s", html)
+	}
+
+	blog, _ = NewBlogWithConfig(Config{}, embed.FS{}, embed.FS{}, embed.FS{})
+	post, _ = blog.parsePost("code.md", content)
+	if strings.Contains(string(post.HTMLContent), "user-select:none") {
+		t.Errorf("Expected no line numbers by default")
+	}
+
+	post, _ = blog.parsePost("plain.md", "---\ntitle: Plain\ndate: 2024-01-27\n---\n```\na < b\n```")
+	if html := string(post.HTMLContent); !strings.Contains(html, `<div class="code-block" data-lang=""><pre><code>a &lt; b`) {
+		t.Errorf("Expected an unhighlighted block to keep its pre element, got %s", html)
+	}
+}
+
+func TestCodeBlockLanguages(t *testing.T) {
+	content := "---\ntitle: Code\ndate: 2024-01-27\n---\n```\nfunc main() {}\n```\n\n```notalanguage\nx := 1 < 2\n```"
+
+	blog, _ := NewBlogWithConfig(Config{}, embed.FS{}, embed.FS{}, embed.FS{})
+	post, err := blog.parsePost("code.md", content)
+	if err != nil {
+		t.Fatalf("Failed to parse post: %v", err)
+	}
+	html := string(post.HTMLContent)
+	if !strings.Contains(html, `<div class="code-block" data-lang=""><pre><code>func main() {}`) {
+		t.Errorf("Expected a block without a language to stay plain, got %s", html)
+	}
+	if !strings.Contains(html, `<div class="code-block" data-lang="notalanguage"><pre><code class="language-notalanguage">x := 1 &lt; 2`) {
+		t.Errorf("Expected a block with an unknown language to stay plain and escaped, got %s", html)
+	}
+
+	blog, _ = NewBlogWithConfig(Config{DefaultCodeLang: "go"}, embed.FS{}, embed.FS{}, embed.FS{})
+	for i := 0; i < 2; i++ {
+		post, err = blog.parsePost("code.md", content)
+		if err != nil {
+			t.Fatalf("Failed to parse post: %v", err)
+		}
+		html = string(post.HTMLContent)
+		if !strings.Contains(html, `<div class="code-block" data-lang="go"><pre style=`) || !strings.Contains(html, `<span style="color:#66d9ef">func</span>`) {
+			t.Errorf("Expected a block without a language to be highlighted as go, got %s", html)
+		}
+		if !strings.Contains(html, `<code class="language-notalanguage">x := 1 &lt; 2`) {
+			t.Errorf("Expected default_code_lang not to apply to an unknown language, got %s", html)
+		}
+	}
+
+	if got := applyConfigDefaults(Config{DefaultCodeLang: "notalanguage"}).DefaultCodeLang; got != "" {
+		t.Errorf("Expected an unknown default_code_lang to be ignored, got %q", got)
+	}
+}
+
+func TestLoadPostsAllowedTags(t *testing.T) {
+	blog, _ := NewBlogWithConfig(Config{
+		AllowedTags: []string{"go", "python", "cafe"},
+		StrictTags:  true,
+	}, embed.FS{}, embed.FS{}, embed.FS{})
+	blog.blogFS = fstest.MapFS{
+		"blog/good.md": {Data: []byte("---\ntitle: Good\ndate: 2024-01-01\ntags: Go, python, Café\n---\nBody")},
+		"blog/typo.md": {Data: []byte("---\ntitle: Typo\ndate: 2024-01-02\ntags: go, pyhton\n---\nBody")},
+	}
+
+	err := blog.LoadPosts()
+	if err == nil {
+		t.Fatalf("Expected an error for an off-list tag in strict mode")
+	}
+	if !strings.Contains(err.Error(), "pyhton") || !strings.Contains(err.Error(), "blog/typo.md") {
+		t.Errorf("Expected error to name the file and tag, got %v", err)
+	}
+	if strings.Contains(err.Error(), "blog/good.md") {
+		t.Errorf("Expected valid post not to be reported, got %v", err)
+	}
+
+	blog.Config.StrictTags = false
+	blog.posts = make(map[string]*Post)
+	blog.postList = nil
+	if err := blog.LoadPosts(); err != nil {
+		t.Errorf("Expected only a warning outside strict mode, got %v", err)
+	}
+}
+
+func TestParsePostFootnotes(t *testing.T) {
+	blog, _ := NewBlog(embed.FS{}, embed.FS{}, embed.FS{})
+	content := `---
+title: Footnotes
+date: 2024-01-27
+---
+## Fn 1
+
+A claim[^1] and another claim[^2].
+
+[^1]: First source.
+[^2]: Second source.`
+
+	post, err := blog.parsePost("footnotes.md", content)
+	if err != nil {
+		t.Fatalf("Failed to parse post: %v", err)
+	}
+	html := string(post.HTMLContent)
+
+	for _, want := range []string{
+		`<div class="footnotes"`,
+		`href="#fn:1"`,
+		`href="#fn:2"`,
+		`href="#fnref:1" class="footnote-backref"`,
+		`href="#fnref:2" class="footnote-backref"`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Expected %s in rendered footnotes, got %s", want, html)
+		}
+	}
+
+	// Heading IDs never contain ':', so they can't clash with footnote anchors.
+	if !strings.Contains(html, `<h2 id="fn-1">`) {
+		t.Errorf("Expected heading id 'fn-1', got %s", html)
+	}
+}
+
+func TestReadingTimeWPM(t *testing.T) {
+	content := "---\ntitle: Dense\ndate: 2024-01-27\n---\n" + strings.Repeat("word ", 600)
+
+	minutes := func(wpm int) int {
+		t.Helper()
+		blog, _ := NewBlogWithConfig(Config{ReadingWPM: wpm}, embed.FS{}, embed.FS{}, embed.FS{})
+		post, err := blog.parsePost("dense.md", content)
+		if err != nil {
+			t.Fatalf("Failed to parse post: %v", err)
+		}
+		return post.ReadingTime
+	}
+
+	if got := minutes(200); got != 3 {
+		t.Errorf("Expected 3 minutes at 200 wpm, got %d", got)
+	}
+	if got := minutes(100); got != 6 {
+		t.Errorf("Expected 6 minutes at 100 wpm, got %d", got)
+	}
+	if got := minutes(-5); got != 3 {
+		t.Errorf("Expected invalid wpm to fall back to 200, got %d minutes", got)
+	}
+}
+
+func TestSeriesPosts(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"part-2.md":    "---\ntitle: Part Two\ndate: 2024-02-01\nseries: Go Tutorial\n---\nBody",
+		"part-1.md":    "---\ntitle: Part One\ndate: 2024-01-01\nseries: Go Tutorial\n---\nBody",
+		"part-3.md":    "---\ntitle: Part Three\ndate: 2024-03-01\nseries: Go Tutorial\n---\nBody",
+		"unrelated.md": "---\ntitle: Unrelated\ndate: 2024-02-15\n---\nBody",
+	})
+
+	series := blog.seriesPosts("Go Tutorial")
+	if len(series) != 3 {
+		t.Fatalf("Expected 3 posts in series, got %d", len(series))
+	}
+	for i, want := range []string{"part-1", "part-2", "part-3"} {
+		if series[i].ID != want {
+			t.Errorf("At index %d, expected %s, got %s", i, want, series[i].ID)
+		}
+	}
+
+	data := blog.postData(blog.posts["part-2"])
+	if data["SeriesPart"] != 2 {
+		t.Errorf("Expected part-2 to be part 2 of the series, got %v", data["SeriesPart"])
+	}
+
+	if got := blog.seriesPosts(""); got != nil {
+		t.Errorf("Expected no series posts for an empty name, got %v", got)
+	}
+	if data := blog.postData(blog.posts["unrelated"]); len(data["Series"].([]*Post)) != 0 {
+		t.Errorf("Expected no series for a standalone post")
+	}
+}
+
+func TestLoadPostsSlugCollision(t *testing.T) {
+	blog, _ := NewBlog(embed.FS{}, embed.FS{}, embed.FS{})
+	blog.blogFS = fstest.MapFS{
+		"blog/Post.md": {Data: []byte("---\ntitle: Upper\ndate: 2024-01-01\n---\nBody")},
+		"blog/post.md": {Data: []byte("---\ntitle: Lower\ndate: 2024-01-02\n---\nBody")},
+	}
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	err := blog.LoadPosts()
+	if err == nil {
+		t.Fatalf("Expected a collision error")
+	}
+	for _, name := range []string{"blog/Post.md", "blog/post.md"} {
+		if !strings.Contains(err.Error(), name) || !strings.Contains(logs.String(), name) {
+			t.Errorf("Expected error and warning to mention %s, got %v / %q", name, err, logs.String())
+		}
+	}
+	if len(blog.postList) != 1 {
+		t.Errorf("Expected only the first post to be kept, got %d posts", len(blog.postList))
+	}
+}
+
+func TestLoadPostsNestedDirectories(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"top.md":        "---\ntitle: Top\ndate: 2024-01-01\n---\nBody",
+		"2024/hello.md": "---\ntitle: Hello\ndate: 2024-02-01\n---\nNested body",
+	})
+
+	post, ok := blog.posts["2024-hello"]
+	if !ok {
+		t.Fatalf("Expected nested post with slug '2024-hello', got %v", blog.posts)
+	}
+	if post.Slug != "2024-hello" || post.Title != "Hello" {
+		t.Errorf("Unexpected nested post %+v", post)
+	}
+	if _, ok := blog.posts["top"]; !ok {
+		t.Errorf("Expected top-level post to keep its slug")
+	}
+
+	distDir := t.TempDir()
+	blog.Export(distDir)
+	if _, err := os.Stat(filepath.Join(distDir, "post", "2024-hello", "index.html")); err != nil {
+		t.Errorf("Expected exported nested post: %v", err)
+	}
+}
+
+func TestExportFiles(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHi.",
+	})
+
+	files := blog.ExportFiles()
+
+	expected := []string{
+		"index.html",
+		"search/index.html",
+		"archive/index.html",
+		"category/index.html",
+		"post/hello/index.html",
+		"404.html",
+		"search-index.json",
+		"slugs.json",
+		"tags.json",
+		"robots.txt",
+		"sitemap.xml",
+		"rss.xml",
+		"atom.xml",
+		"feed.json",
+		"manifest.webmanifest",
+		"sw.js",
+	}
+	for _, name := range expected {
+		if len(files[name]) == 0 {
+			t.Errorf("Expected non-empty %s in exported files", name)
+		}
+	}
+	for name, data := range files {
+		if strings.HasPrefix(name, "static/") {
+			continue
+		}
+		if !contains(expected, name) {
+			t.Errorf("Unexpected exported file %s", name)
+		}
+		if strings.HasSuffix(name, ".html") && !bytes.Contains(data, []byte("<title>")) {
+			t.Errorf("Expected %s to render an HTML page, got %q", name, data)
+		}
+	}
+}
+
+func TestExportRefusesUnrelatedDir(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHi.",
+	})
+
+	distDir := t.TempDir()
+	important := filepath.Join(distDir, "notes.txt")
+	os.WriteFile(important, []byte("keep me"), 0644)
+
+	if err := blog.Export(distDir); err == nil {
+		t.Fatalf("Expected Export to refuse a non-empty directory without a previous export")
+	}
+	if data, err := os.ReadFile(important); err != nil || string(data) != "keep me" {
+		t.Errorf("Expected unrelated file to be left untouched, got %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(distDir, "index.html")); err == nil {
+		t.Errorf("Expected nothing to be exported into the refused directory")
+	}
+}
+
+func TestExportRefusesSiteWithoutMarker(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHi.",
+	})
+
+	// Someone else's site: an index.html is not enough to count as ours.
+	distDir := t.TempDir()
+	index := filepath.Join(distDir, "index.html")
+	os.WriteFile(index, []byte("<h1>My other site</h1>"), 0644)
+	os.WriteFile(filepath.Join(distDir, "about.html"), []byte("about"), 0644)
+
+	if err := blog.Export(distDir); err == nil {
+		t.Fatalf("Expected Export to refuse a directory with index.html but no %s", exportMarker)
+	}
+	if data, err := os.ReadFile(index); err != nil || string(data) != "<h1>My other site</h1>" {
+		t.Errorf("Expected index.html to be left untouched, got %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(distDir, exportMarker)); err == nil {
+		t.Errorf("Expected no marker to be written into the refused directory")
+	}
+}
+
+func TestExportReplacesPreviousExport(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHi.",
+	})
+
+	distDir := filepath.Join(t.TempDir(), "dist")
+	if err := blog.Export(distDir); err != nil {
+		t.Fatalf("Failed to export into a new directory: %v", err)
+	}
+	stale := filepath.Join(distDir, "stale.html")
+	os.WriteFile(stale, []byte("old"), 0644)
+
+	if err := blog.Export(distDir); err != nil {
+		t.Fatalf("Failed to re-export over a previous export: %v", err)
+	}
+	if _, err := os.Stat(stale); err == nil {
+		t.Errorf("Expected re-export to remove stale files")
+	}
+	if _, err := os.Stat(filepath.Join(distDir, exportMarker)); err != nil {
+		t.Errorf("Expected export marker: %v", err)
+	}
+
+	// Exports made before the marker existed are only replaced with force.
+	os.Remove(filepath.Join(distDir, exportMarker))
+	if err := blog.Export(distDir); err == nil {
+		t.Errorf("Expected export over a directory without a marker to be refused")
+	}
+	if _, err := os.Stat(filepath.Join(distDir, "index.html")); err != nil {
+		t.Errorf("Expected the refused directory to be left untouched: %v", err)
+	}
+	if _, err := blog.ExportIncremental(distDir, true); err != nil {
+		t.Errorf("Failed to force a re-export over an export without a marker: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(distDir, exportMarker)); err != nil {
+		t.Errorf("Expected a forced export to write the marker: %v", err)
+	}
+}
+
+func TestExportMinifyHTML(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"code.md": "---\ntitle: Code\ndate: 2024-01-27\n---\n<!-- draft note -->\n\n```\nfunc main() {\n    if  x  {\n\n        return\n    }\n}\n```\n",
+	})
+
+	export := func(minifyHTML bool) string {
+		blog.Config.MinifyHTML = &minifyHTML
+		distDir := t.TempDir()
+		blog.Export(distDir)
+		page, err := os.ReadFile(filepath.Join(distDir, "post", "code", "index.html"))
+		if err != nil {
+			t.Fatalf("Failed to read exported post: %v", err)
+		}
+		return string(page)
+	}
+	// preText returns the text of the first <pre> block without its markup.
+	preText := func(page string) string {
+		start := strings.Index(page, "<pre")
+		end := strings.Index(page, "</pre>")
+		if start < 0 || end < start {
+			t.Fatalf("Expected a <pre> block in exported post")
+		}
+		return regexp.MustCompile(`<[^>]*>`).ReplaceAllString(page[start:end], "")
+	}
+
+	plain, minified := export(false), export(true)
+	if len(minified) >= len(plain) {
+		t.Errorf("Expected minified page (%d bytes) to be smaller than plain (%d bytes)", len(minified), len(plain))
+	}
+	if got, want := preText(minified), preText(plain); got != want {
+		t.Errorf("Expected code block untouched by minification, got %q, want %q", got, want)
+	}
+	if !strings.Contains(preText(minified), "    if  x  {\n\n        return") {
+		t.Errorf("Expected code indentation preserved, got %q", preText(minified))
+	}
+}
+
+func TestParsePostMissingTitle(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	blog := newTestBlog(t, map[string]string{
+		"my-first-post.md": "---\ndate: 2024-01-27\n---\nNo title here.",
+		"empty-title.md":   "---\ntitle:\ndate: 2024-01-28\n---\nBlank title.",
+	})
+
+	if got := blog.posts["my-first-post"].Title; got != "My First Post" {
+		t.Errorf("Expected derived title 'My First Post', got %q", got)
+	}
+	if got := blog.posts["empty-title"].Title; got != "Empty Title" {
+		t.Errorf("Expected derived title 'Empty Title', got %q", got)
+	}
+	if !strings.Contains(logs.String(), "my-first-post.md") {
+		t.Errorf("Expected a warning about my-first-post.md, got %q", logs.String())
+	}
+	if results := blog.search("first"); len(results) != 1 || results[0].ID != "my-first-post" {
+		t.Errorf("Expected derived title to be searchable, got %v", results)
+	}
+}
+
+func TestHomeFeaturedPosts(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"old-pinned.md": "---\ntitle: Old Pinned\ndate: 2020-01-01\nfeatured: true\n---\nA.",
+		"second.md":     "---\ntitle: Second\ndate: 2021-01-01\nfeatured_order: 2\n---\nB.",
+		"first.md":      "---\ntitle: First\ndate: 2019-01-01\nfeatured_order: 1\n---\nC.",
+		"regular.md":    "---\ntitle: Regular\ndate: 2024-01-01\n---\nD.",
+		"not-pinned.md": "---\ntitle: Not Pinned\ndate: 2023-01-01\nfeatured: false\n---\nE.",
+	})
+
+	data := blog.homeData()
+	var featured, posts []string
+	for _, post := range data["Featured"].([]*Post) {
+		featured = append(featured, post.ID)
+	}
+	for _, post := range data["Posts"].([]*Post) {
+		posts = append(posts, post.ID)
+	}
+
+	if want := []string{"first", "second", "old-pinned"}; !reflect.DeepEqual(featured, want) {
+		t.Errorf("Expected featured posts %v, got %v", want, featured)
+	}
+	if want := []string{"regular", "not-pinned"}; !reflect.DeepEqual(posts, want) {
+		t.Errorf("Expected regular posts %v without featured ones, got %v", want, posts)
+	}
+	if len(blog.postList) != 5 {
+		t.Errorf("Expected featured posts to stay in postList, got %d posts", len(blog.postList))
+	}
+}
+
+func TestPostsPerPageConfig(t *testing.T) {
+	if got := applyConfigDefaults(Config{}).PostsPerPage; got != 10 {
+		t.Errorf("Expected default posts_per_page 10, got %d", got)
+	}
+	if got := applyConfigDefaults(Config{PostsPerPage: -3}).PostsPerPage; got != 10 {
+		t.Errorf("Expected invalid posts_per_page to fall back to 10, got %d", got)
+	}
+
+	config, err := parseConfig([]byte("posts_per_page: 5"), "")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+	if config.PostsPerPage != 5 {
+		t.Errorf("Expected configured posts_per_page 5, got %d", config.PostsPerPage)
+	}
+}
+
+func TestParsePostSlugOverride(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"2024-01-27-my-first-post.md": "---\ntitle: Hello\ndate: 2024-01-27\nslug: hello-world\n---\nHi.",
+		"draft notes.md":              "---\ntitle: Notes\ndate: 2024-01-28\nslug: my notes\n---\nText.",
+	})
+
+	post, ok := blog.posts["hello-world"]
+	if !ok {
+		t.Fatalf("Expected post under the overridden slug, got %v", blog.posts)
+	}
+	if post.Slug != "hello-world" || post.ID != "hello-world" {
+		t.Errorf("Expected ID and Slug 'hello-world', got '%s' and '%s'", post.ID, post.Slug)
+	}
+
+	if _, ok := blog.posts["draft notes"]; !ok {
+		t.Errorf("Expected an invalid slug override to fall back to the filename slug, got %v", blog.posts)
+	}
+}
+
+func TestLazyImages(t *testing.T) {
+	content := "---\ntitle: Photos\ndate: 2024-01-27\n---\n![A sleeping cat](cat.png)\n\nInline ![](dog.png) image."
+
+	blog, _ := NewBlogWithConfig(Config{LazyImages: true}, embed.FS{}, embed.FS{}, embed.FS{})
+	post, err := blog.parsePost("photos.md", content)
+	if err != nil {
+		t.Fatalf("Failed to parse post: %v", err)
+	}
+	html := string(post.HTMLContent)
+
+	want := `<figure><img src="cat.png" alt="A sleeping cat" loading="lazy" decoding="async" /><figcaption>A sleeping cat</figcaption></figure>`
+	if !strings.Contains(html, want) {
+		t.Errorf("Expected captioned lazy image %s, got %s", want, html)
+	}
+	if !strings.Contains(html, `<p>Inline <img src="dog.png" alt="" loading="lazy" decoding="async" /> image.</p>`) {
+		t.Errorf("Expected inline image to stay in its paragraph without a caption, got %s", html)
+	}
+
+	blog, _ = NewBlogWithConfig(Config{}, embed.FS{}, embed.FS{}, embed.FS{})
+	post, _ = blog.parsePost("photos.md", content)
+	if strings.Contains(string(post.HTMLContent), "loading=") || strings.Contains(string(post.HTMLContent), "<figure>") {
+		t.Errorf("Expected images untouched by default, got %s", post.HTMLContent)
+	}
+}
+
+func TestHardWrapsConfig(t *testing.T) {
+	content := "---\ntitle: Prose\ndate: 2024-01-27\n---\nFirst line\nsecond line."
+
+	render := func(config Config) string {
+		t.Helper()
+		blog, _ := NewBlogWithConfig(config, embed.FS{}, embed.FS{}, embed.FS{})
+		post, err := blog.parsePost("prose.md", content)
+		if err != nil {
+			t.Fatalf("Failed to parse post: %v", err)
+		}
+		return string(post.HTMLContent)
+	}
+
+	if html := render(Config{}); !strings.Contains(html, "<br />") {
+		t.Errorf("Expected hard wraps by default, got %s", html)
+	}
+
+	off := false
+	if html := render(Config{HardWraps: &off}); strings.Contains(html, "<br") {
+		t.Errorf("Expected no <br> with hard_wraps disabled, got %s", html)
+	}
+}
+
+func TestNewBlogTemplates(t *testing.T) {
+	blog, err := NewBlog(embed.FS{}, embed.FS{}, embed.FS{})
+	if err != nil {
+		t.Fatalf("Expected an empty template FS to be tolerated, got %v", err)
+	}
+	if blog.templates != nil {
+		t.Errorf("Expected no templates for an empty FS")
+	}
+
+	malformed := fstest.MapFS{
+		"templates/index.html": {Data: []byte("<html>{{.Broken</html>")},
+	}
+	if _, err := NewBlog(malformed, embed.FS{}, embed.FS{}); err == nil || !strings.Contains(err.Error(), "failed to parse templates") {
+		t.Errorf("Expected a parse error for malformed templates, got %v", err)
+	}
+}
+
+func TestParsePostFrontmatterErrors(t *testing.T) {
+	blog, _ := NewBlog(embed.FS{}, embed.FS{}, embed.FS{})
+
+	_, err := blog.parsePost("plain.md", "# Just markdown\n\nSome text\n\n---\n\nMore text.")
+	if !errors.Is(err, errNoFrontmatter) {
+		t.Errorf("Expected a no-frontmatter error, got %v", err)
+	}
+
+	_, err = blog.parsePost("open.md", "---\ntitle: Open\ndate: 2024-01-27\n# Body without a closing fence")
+	if !errors.Is(err, errUnterminatedFrontmatter) {
+		t.Errorf("Expected an unterminated-frontmatter error, got %v", err)
+	}
+
+	post, err := blog.parsePost("rule.md", "---\ntitle: Rule\ndate: 2024-01-27\n---\nAbove the rule.\n\n---\n\ntitle: not frontmatter")
+	if err != nil {
+		t.Fatalf("Failed to parse post: %v", err)
+	}
+	if post.Title != "Rule" {
+		t.Errorf("Expected title 'Rule', got '%s'", post.Title)
+	}
+	if !strings.Contains(string(post.HTMLContent), "<hr") || !strings.Contains(string(post.HTMLContent), "title: not frontmatter") {
+		t.Errorf("Expected the body --- to render as a horizontal rule, got %s", post.HTMLContent)
+	}
+}
+
+func TestParsePostTagSyntaxes(t *testing.T) {
+	blog, _ := NewBlog(embed.FS{}, embed.FS{}, embed.FS{})
+	want := []string{"go", "web", "tutorial"}
+
+	for name, tags := range map[string]string{
+		"comma": "tags: go, web, tutorial,",
+		"flow":  "tags: [go, \"web\", tutorial]",
+		"block": "tags:\n  - go\n  - web\n  - 'tutorial'",
+	} {
+		post, err := blog.parsePost(name+".md", "---\ntitle: Tags\n"+tags+"\ndate: 2024-01-27\n---\nBody.")
+		if err != nil {
+			t.Fatalf("%s: failed to parse post: %v", name, err)
+		}
+		if !reflect.DeepEqual(post.Tags, want) {
+			t.Errorf("%s: expected tags %v, got %v", name, want, post.Tags)
+		}
+		if post.Date.IsZero() || post.Date.Year() != 2024 {
+			t.Errorf("%s: expected the date after the tags to be parsed, got %v", name, post.Date)
+		}
+	}
+}
+
+func TestParsePostUpdated(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"revised.md": "---\ntitle: Revised\ndate: 2024-01-27\nupdated: 2024-06-01\n---\nBody.",
+		"plain.md":   "---\ntitle: Plain\ndate: 2024-02-01\n---\nBody.",
+	})
+
+	revised := blog.posts["revised"]
+	if want, _ := time.Parse("2006-01-02", "2024-06-01"); !revised.Updated.Equal(want) {
+		t.Errorf("Expected updated %v, got %v", want, revised.Updated)
+	}
+	if plain := blog.posts["plain"]; !plain.Updated.Equal(plain.Date) {
+		t.Errorf("Expected updated to default to the date, got %v", plain.Updated)
+	}
+
+	if got := feedUpdated(blog.feedItems()); !got.Equal(revised.Updated) {
+		t.Errorf("Expected feeds to be updated at %v, got %v", revised.Updated, got)
+	}
+
+	rec := httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/post/revised/", nil))
+	if !strings.Contains(rec.Body.String(), "Updated on") || !strings.Contains(rec.Body.String(), "June 1, 2024") {
+		t.Errorf("Expected the post page to show the update date")
+	}
+	rec = httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/post/plain/", nil))
+	if strings.Contains(rec.Body.String(), "Updated on") {
+		t.Errorf("Expected no update note for a post that was never revised")
+	}
+}
+
+func TestRenderDefinitionListsAndTaskLists(t *testing.T) {
+	blog, _ := NewBlog(embed.FS{}, embed.FS{}, embed.FS{})
+	content := "---\ntitle: Lists\ndate: 2024-01-27\n---\nGo\n: A programming language.\n\n- [x] done\n- [ ] todo\n"
+	post, err := blog.parsePost("lists.md", content)
+	if err != nil {
+		t.Fatalf("Failed to parse post: %v", err)
+	}
+	html := string(post.HTMLContent)
+
+	for _, want := range []string{
+		"<dl>\n<dt>Go</dt>\n<dd>A programming language.</dd>\n</dl>",
+		`<li><input checked="" disabled="" type="checkbox" /> done</li>`,
+		`<li><input disabled="" type="checkbox" /> todo</li>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Expected %q in rendered HTML, got %s", want, html)
+		}
+	}
+}
+
+func TestPostWeightOrdering(t *testing.T) {
+	order := func(blog *Blog) []string {
+		var ids []string
+		for _, post := range blog.postList {
+			ids = append(ids, post.ID)
+		}
+		return ids
+	}
+
+	dated := newTestBlog(t, map[string]string{
+		"old.md": "---\ntitle: Old\ndate: 2024-01-01\n---\nA.",
+		"new.md": "---\ntitle: New\ndate: 2024-03-01\n---\nB.",
+	})
+	if got, want := order(dated), []string{"new", "old"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected date order %v without weights, got %v", want, got)
+	}
+
+	blog := newTestBlog(t, map[string]string{
+		"intro.md":    "---\ntitle: Intro\ndate: 2024-01-01\nweight: 1\n---\nA.",
+		"setup.md":    "---\ntitle: Setup\ndate: 2024-02-01\nweight: 2\n---\nB.",
+		"advanced.md": "---\ntitle: Advanced\ndate: 2023-06-01\nweight: 3\n---\nC.",
+		"news.md":     "---\ntitle: News\ndate: 2024-05-01\n---\nD.",
+		"aside.md":    "---\ntitle: Aside\ndate: 2024-04-01\nweight: nope\n---\nE.",
+	})
+	if got, want := order(blog), []string{"intro", "setup", "advanced", "news", "aside"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected weighted posts first, got %v, want %v", got, want)
+	}
+
+	blog.AddMarkdown("extra.md", "---\ntitle: Extra\ndate: 2022-01-01\nweight: 2\n---\nF.")
+	if got, want := order(blog), []string{"intro", "setup", "extra", "advanced", "news", "aside"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected AddPost to keep weight order, got %v, want %v", got, want)
+	}
+}
+
+func TestLoadPostsReportsBrokenPosts(t *testing.T) {
+	blog, _ := NewBlog(embed.FS{}, embed.FS{}, embed.FS{})
+	blog.blogFS = fstest.MapFS{
+		"blog/good.md":   {Data: []byte("---\ntitle: Good\ndate: 2024-01-01\n---\nBody")},
+		"blog/broken.md": {Data: []byte("---\ntitle: Broken\ndate: 2024-01-02\nNo closing fence.")},
+	}
+
+	err := blog.LoadPosts()
+	if len(blog.postList) != 1 || blog.posts["good"] == nil {
+		t.Fatalf("Expected the good post to load, got %v", blog.postList)
+	}
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) {
+		t.Fatalf("Expected a LoadError, got %v", err)
+	}
+	if loadErr.Path != "blog/broken.md" || !errors.Is(err, errUnterminatedFrontmatter) {
+		t.Errorf("Expected the broken post's path and cause, got %v", loadErr)
+	}
+	if strings.Contains(err.Error(), "good.md") {
+		t.Errorf("Expected the good post not to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package blog
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Category is a post's primary topic. Unlike tags, each post has at most
+// one, so categories can make up a site's top-level navigation.
+type Category struct {
+	Name  string // as written by the category's newest post
+	Slug  string
+	Posts []*Post // in listing order
+}
+
+// categories groups the posts by the slug of their category, alphabetically
+// by name. Posts without a category are left out.
+func (b *Blog) categories() []*Category {
+	bySlug := make(map[string]*Category)
+	var categories []*Category
+	for _, post := range b.postList {
+		if post.Category == "" {
+			continue
+		}
+		slug := slugify(post.Category)
+		category, ok := bySlug[slug]
+		if !ok {
+			category = &Category{Name: post.Category, Slug: slug}
+			bySlug[slug] = category
+			categories = append(categories, category)
+		}
+		category.Posts = append(category.Posts, post)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		return foldCase(categories[i].Name) < foldCase(categories[j].Name)
+	})
+	return categories
+}
+
+// categoryData builds the page listing a category's posts, or with an
+// empty slug the index of all categories. It reports false for a slug no
+// post's category has.
+func (b *Blog) categoryData(slug string) (map[string]interface{}, bool) {
+	categories := b.categories()
+	data := map[string]interface{}{
+		"Title":      "Categories",
+		"Categories": categories,
+		"Config":     b.Config,
+		"Theme":      b.Config.DefaultTheme,
+	}
+	if slug == "" {
+		return data, true
+	}
+	for _, category := range categories {
+		if category.Slug == slug {
+			data["Title"] = category.Name
+			data["Category"] = category
+			return data, true
+		}
+	}
+	return nil, false
+}
+
+func (b *Blog) handleCategory(w http.ResponseWriter, r *http.Request) {
+	slug := strings.Trim(strings.TrimPrefix(r.URL.Path, "/category"), "/")
+	data, ok := b.categoryData(slug)
+	if !ok {
+		b.notFound(w, r, "")
+		return
+	}
+	data["Theme"] = b.themeFor(r)
+	data["Nonce"] = cspNonce(r)
+	b.render(w, "category.html", data)
+}
@@ -0,0 +1,71 @@
+package blog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCategories(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"a.md": "---\ntitle: A\ndate: 2024-01-01\ncategory: Tutorials\n---\nBody",
+		"b.md": "---\ntitle: B\ndate: 2024-02-01\ncategory: tutorials\n---\nBody",
+		"c.md": "---\ntitle: C\ndate: 2024-03-01\ncategory: Essays\n---\nBody",
+		"d.md": "---\ntitle: D\ndate: 2024-04-01\n---\nBody",
+		"e.md": "---\ntitle: E\ndate: 2024-05-01\ncategory: Drafts\ndraft: true\n---\nBody",
+	})
+
+	categories := blog.categories()
+	if len(categories) != 2 {
+		t.Fatalf("Expected 2 categories, got %+v", categories)
+	}
+	if categories[0].Name != "Essays" || len(categories[0].Posts) != 1 {
+		t.Errorf("Expected Essays first with one post, got %+v", categories[0])
+	}
+	tutorials := categories[1]
+	if tutorials.Name != "tutorials" || tutorials.Slug != "tutorials" {
+		t.Errorf("Expected the newest post's spelling of the tutorials category, got %+v", tutorials)
+	}
+	if len(tutorials.Posts) != 2 || tutorials.Posts[0].ID != "b" || tutorials.Posts[1].ID != "a" {
+		t.Errorf("Expected tutorials posts [b a], got %v", tutorials.Posts)
+	}
+
+	router := blog.Router()
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := get("/category/tutorials/")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 from a category page, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `href="/post/a/"`) || !strings.Contains(body, `href="/post/b/"`) || strings.Contains(body, `href="/post/c/"`) {
+		t.Errorf("Expected the category page to list only its posts, got %s", body)
+	}
+
+	rec = get("/category/")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `href="/category/essays/"`) {
+		t.Errorf("Expected the categories index to link each category, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	for _, path := range []string{"/category/recipes/", "/category/drafts/", "/category/tutorials/extra/"} {
+		if rec := get(path); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 from %s, got %d", path, rec.Code)
+		}
+	}
+
+	if got := newSearchIndexPost(blog.posts["c"]).Category; got != "Essays" {
+		t.Errorf("Expected the category in the search index, got %q", got)
+	}
+
+	files := blog.ExportFiles()
+	for _, name := range []string{"category/index.html", "category/tutorials/index.html", "category/essays/index.html"} {
+		if len(files[name]) == 0 {
+			t.Errorf("Expected %s in the export", name)
+		}
+	}
+}
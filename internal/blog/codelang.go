@@ -0,0 +1,54 @@
+package blog
+
+import (
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// defaultCodeLang renders fenced code blocks that name no language as if
+// they named lang, for Config.DefaultCodeLang. It wraps the highlighting
+// renderer rather than guessing the language, since chroma's guesses turn
+// plain text such as shell output into scattered keyword colors.
+type defaultCodeLang struct {
+	lang  []byte
+	inner renderer.NodeRenderer
+}
+
+func (d defaultCodeLang) SetOption(name renderer.OptionName, value interface{}) {
+	if s, ok := d.inner.(renderer.SetOptioner); ok {
+		s.SetOption(name, value)
+	}
+}
+
+func (d defaultCodeLang) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	d.inner.RegisterFuncs(defaultCodeLangRegisterer{reg, d.lang})
+}
+
+// defaultCodeLangRegisterer passes the inner renderer's functions through,
+// wrapping the one for fenced code blocks.
+type defaultCodeLangRegisterer struct {
+	renderer.NodeRendererFuncRegisterer
+	lang []byte
+}
+
+func (r defaultCodeLangRegisterer) Register(kind ast.NodeKind, render renderer.NodeRendererFunc) {
+	if kind != ast.KindFencedCodeBlock {
+		r.NodeRendererFuncRegisterer.Register(kind, render)
+		return
+	}
+	lang := r.lang
+	r.NodeRendererFuncRegisterer.Register(kind, func(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+		n := node.(*ast.FencedCodeBlock)
+		if !entering || n.Info != nil {
+			return render(w, source, node, entering)
+		}
+		// The info string must be a segment of the source, so the block
+		// is rendered against a copy of the source with lang appended.
+		extended := append(source[:len(source):len(source)], lang...)
+		n.Info = ast.NewTextSegment(text.NewSegment(len(source), len(extended)))
+		defer func() { n.Info = nil }()
+		return render(w, extended, node, entering)
+	})
+}
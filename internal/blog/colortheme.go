@@ -0,0 +1,41 @@
+package blog
+
+import (
+	"net/http"
+	"time"
+)
+
+// themeCookie holds the reader's chosen color theme so pages can be
+// rendered with it and avoid a flash of the wrong theme.
+const themeCookie = "theme"
+
+func validTheme(theme string) bool {
+	return theme == "dark" || theme == "light"
+}
+
+// themeFor returns the color theme from the request's cookie, or
+// Config.DefaultTheme when it has none.
+func (b *Blog) themeFor(r *http.Request) string {
+	if cookie, err := r.Cookie(themeCookie); err == nil && validTheme(cookie.Value) {
+		return cookie.Value
+	}
+	return b.Config.DefaultTheme
+}
+
+// handleTheme stores the theme posted as theme=dark|light in a cookie.
+func (b *Blog) handleTheme(w http.ResponseWriter, r *http.Request) {
+	theme := r.FormValue("theme")
+	if !validTheme(theme) {
+		writeJSONError(w, http.StatusBadRequest, `theme must be "dark" or "light"`)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     themeCookie,
+		Value:    theme,
+		Path:     "/",
+		MaxAge:   int((365 * 24 * time.Hour).Seconds()),
+		SameSite: http.SameSiteLaxMode,
+	})
+	writeJSON(w, http.StatusOK, map[string]string{"theme": theme})
+}
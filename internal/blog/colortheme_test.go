@@ -0,0 +1,73 @@
+package blog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestHandleThemeSetsCookie(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHello.",
+	})
+	router := blog.Router()
+
+	form := url.Values{"theme": {"light"}}
+	req := httptest.NewRequest(http.MethodPost, "/api/theme", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "theme" || cookies[0].Value != "light" {
+		t.Fatalf("Expected a theme=light cookie, got %v", cookies)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/theme", strings.NewReader("theme=purple"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || len(rec.Result().Cookies()) != 0 {
+		t.Errorf("Expected an invalid theme to be rejected without a cookie, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/theme", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for GET, got %d", rec.Code)
+	}
+}
+
+func TestThemeCookieRendersTheme(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHello.",
+	})
+	router := blog.Router()
+
+	for _, path := range []string{"/", "/post/hello/", "/search/"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.AddCookie(&http.Cookie{Name: "theme", Value: "light"})
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if !strings.Contains(rec.Body.String(), `<html data-theme="light">`) {
+			t.Errorf("%s: expected the cookie's light theme on <html>", path)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(rec.Body.String(), `<html data-theme="dark">`) {
+		t.Errorf("Expected the default dark theme without a cookie")
+	}
+
+	blog.Config.DefaultTheme = "light"
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(rec.Body.String(), `<html data-theme="light">`) {
+		t.Errorf("Expected the configured default theme without a cookie")
+	}
+}
@@ -0,0 +1,52 @@
+package blog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CommentsConfig embeds GitHub-backed comments under posts. Comments are
+// off unless Provider is set.
+type CommentsConfig struct {
+	Provider   string `yaml:"provider"`    // "giscus" (GitHub Discussions) or "utterances" (GitHub Issues)
+	Repo       string `yaml:"repo"`        // public repository holding the comments, as owner/name
+	RepoID     string `yaml:"repo_id"`     // giscus only; shown on giscus.app
+	Category   string `yaml:"category"`    // giscus only; discussion category for new threads
+	CategoryID string `yaml:"category_id"` // giscus only; shown on giscus.app
+}
+
+// validate reports settings the configured provider can't work with.
+func (c CommentsConfig) validate() error {
+	switch c.Provider {
+	case "":
+		return nil
+	case "giscus":
+		if c.RepoID == "" || c.Category == "" || c.CategoryID == "" {
+			return errors.New("giscus needs repo_id, category and category_id")
+		}
+	case "utterances":
+	default:
+		return fmt.Errorf("unknown provider %q, want giscus or utterances", c.Provider)
+	}
+	if owner, name, ok := strings.Cut(c.Repo, "/"); !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return fmt.Errorf("repo %q must look like owner/name", c.Repo)
+	}
+	return nil
+}
+
+// commentsEmbed is what post.html needs to render a comments script.
+// Threads are mapped to posts by slug, so they survive title changes.
+type commentsEmbed struct {
+	CommentsConfig
+	Term string
+}
+
+// commentsData returns the comments embed for post, or nil when comments
+// are off.
+func (b *Blog) commentsData(post *Post) *commentsEmbed {
+	if b.Config.Comments.Provider == "" {
+		return nil
+	}
+	return &commentsEmbed{CommentsConfig: b.Config.Comments, Term: post.Slug}
+}
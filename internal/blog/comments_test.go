@@ -0,0 +1,50 @@
+package blog
+
+import (
+	"embed"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestComments(t *testing.T) {
+	render := func(comments CommentsConfig) string {
+		root := os.DirFS("../..")
+		blog, _ := NewBlogWithConfig(Config{Comments: comments}, root, root, embed.FS{})
+		blog.blogFS = fstest.MapFS{
+			"blog/hello.md": {Data: []byte("---\ntitle: Hello\ndate: 2024-01-27\n---\nHi.")},
+		}
+		if err := blog.LoadPosts(); err != nil {
+			t.Fatalf("Failed to load posts: %v", err)
+		}
+		rec := httptest.NewRecorder()
+		blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/post/hello/", nil))
+		return rec.Body.String()
+	}
+
+	if body := render(CommentsConfig{}); strings.Contains(body, `class="comments"`) {
+		t.Errorf("Expected no comments without a provider")
+	}
+
+	body := render(CommentsConfig{Provider: "utterances", Repo: "octo/blog-comments"})
+	if !strings.Contains(body, `src="https://utteranc.es/client.js" repo="octo/blog-comments" issue-term="hello"`) {
+		t.Errorf("Expected an utterances embed mapped by slug, got %s", body)
+	}
+
+	body = render(CommentsConfig{Provider: "giscus", Repo: "octo/blog-comments", RepoID: "R_1", Category: "Comments", CategoryID: "DIC_1"})
+	if !strings.Contains(body, `src="https://giscus.app/client.js" data-repo="octo/blog-comments" data-repo-id="R_1"`) ||
+		!strings.Contains(body, `data-mapping="specific"`) || !strings.Contains(body, `data-term="hello"`) {
+		t.Errorf("Expected a giscus embed mapped by slug, got %s", body)
+	}
+
+	// Incomplete settings turn comments off instead of embedding a broken widget.
+	if body := render(CommentsConfig{Provider: "giscus", Repo: "octo/blog-comments"}); strings.Contains(body, "giscus.app/client.js") {
+		t.Errorf("Expected giscus without its IDs to be disabled")
+	}
+	if body := render(CommentsConfig{Provider: "disqus", Repo: "octo/blog-comments"}); strings.Contains(body, `class="comments"`) {
+		t.Errorf("Expected an unknown provider to be disabled")
+	}
+}
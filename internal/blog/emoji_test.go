@@ -0,0 +1,39 @@
+package blog
+
+import (
+	"embed"
+	"strings"
+	"testing"
+)
+
+func renderWithConfig(t *testing.T, config Config, markdown string) string {
+	t.Helper()
+	blog, _ := NewBlogWithConfig(config, embed.FS{}, embed.FS{}, embed.FS{})
+	post, err := blog.parsePost("emoji.md", "---\ntitle: Emoji\ndate: 2024-01-27\n---\n"+markdown)
+	if err != nil {
+		t.Fatalf("Failed to parse post: %v", err)
+	}
+	return string(post.HTMLContent)
+}
+
+func TestEmojiShortcodes(t *testing.T) {
+	html := renderWithConfig(t, Config{}, "Shipped :tada: at 10:30:00, :not_an_emoji: stays.")
+	if !strings.Contains(html, "Shipped 🎉 at 10:30:00, :not_an_emoji: stays.") {
+		t.Errorf("Expected :tada: to render as 🎉 and other colons to stay, got %s", html)
+	}
+
+	off := false
+	if html := renderWithConfig(t, Config{Emoji: &off}, "Shipped :tada:"); !strings.Contains(html, ":tada:") {
+		t.Errorf("Expected shortcodes left alone with emoji disabled, got %s", html)
+	}
+}
+
+func TestEmojiShortcodesInCode(t *testing.T) {
+	html := renderWithConfig(t, Config{}, "Inline `:tada:` code.\n\n```\necho :tada:\n```\n")
+	if strings.Contains(html, "🎉") {
+		t.Errorf("Expected shortcodes in code to stay literal, got %s", html)
+	}
+	if strings.Count(html, ":tada:") != 2 {
+		t.Errorf("Expected both code shortcodes to be kept, got %s", html)
+	}
+}
@@ -0,0 +1,63 @@
+package blog
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/text"
+)
+
+const excerptLength = 160
+
+var plainTextParser = goldmark.New(goldmark.WithExtensions(extension.GFM)).Parser()
+
+// plainText returns the readable text of a markdown document with all
+// markup, raw HTML and code blocks removed and whitespace collapsed. The
+// result is unescaped; templates and encoders escape it for their output.
+func plainText(markdown string) string {
+	source := []byte(markdown)
+	doc := plainTextParser.Parse(text.NewReader(source))
+
+	var sb strings.Builder
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		switch t := n.(type) {
+		case *ast.FencedCodeBlock, *ast.CodeBlock, *ast.HTMLBlock, *ast.RawHTML:
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			if entering {
+				sb.Write(t.Segment.Value(source))
+				if t.SoftLineBreak() || t.HardLineBreak() {
+					sb.WriteByte(' ')
+				}
+			}
+		case *ast.String:
+			if entering {
+				sb.Write(t.Value)
+			}
+		default:
+			// Keep words in neighbouring blocks apart.
+			if !entering && n.Type() == ast.TypeBlock {
+				sb.WriteByte(' ')
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// excerpt shortens s to at most n characters, cutting at a word boundary
+// and marking the cut with an ellipsis.
+func excerpt(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	cut := string([]rune(s)[:n])
+	if i := strings.LastIndexByte(cut, ' '); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,.;:") + "…"
+}
@@ -0,0 +1,55 @@
+package blog
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPlainText(t *testing.T) {
+	markdown := "# Intro\n\nSome **bold** and _emphasis_ with a [link](https://example.com \"title\").\n" +
+		"Call `fmt.Println(\"hi\")` & compare a < b.\n\n" +
+		"```go\nfunc main() {}\n```\n\n<div class=\"note\">raw</div>\n\nDone."
+
+	got := plainText(markdown)
+	want := `Intro Some bold and emphasis with a link. Call fmt.Println("hi") & compare a < b. Done.`
+	if got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	if got := excerpt("short text", 160); got != "short text" {
+		t.Errorf("Expected short text unchanged, got %q", got)
+	}
+	if got := excerpt("one two three four", 10); got != "one two…" {
+		t.Errorf("Expected cut at a word boundary, got %q", got)
+	}
+}
+
+func TestExcerptInMetaAndFeeds(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nTom & \"Jerry\" say <b>hi</b> in **bold**.",
+	})
+
+	post := blog.posts["hello"]
+	if post.Excerpt != `Tom & "Jerry" say hi in bold.` {
+		t.Fatalf("Unexpected excerpt %q", post.Excerpt)
+	}
+
+	rec := httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/post/hello/", nil))
+	if html := rec.Body.String(); !strings.Contains(html, `content="Tom &amp; &#34;Jerry&#34; say hi in bold."`) {
+		t.Errorf("Expected escaped excerpt in meta description, got %s", html)
+	}
+
+	var feed rssFeed
+	if err := xml.Unmarshal(blog.rssXML(), &feed); err != nil {
+		t.Fatalf("Failed to parse RSS feed: %v", err)
+	}
+	if got := feed.Channel.Items[0].Description; got != post.Excerpt {
+		t.Errorf("Expected RSS description to be the excerpt, got %q", got)
+	}
+}
@@ -0,0 +1,196 @@
+package blog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+	"time"
+)
+
+// syntheticPosts returns n short posts with distinct slugs, dates and words.
+func syntheticPosts(n int) map[string]string {
+	words := strings.Fields("server cache index query latency thread socket buffer parser token schema replica shard queue")
+	tags := []string{"go", "web", "databases", "testing"}
+	date := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	files := make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		var body strings.Builder
+		for j := 0; j < 40; j++ {
+			body.WriteString(words[(i+j*7)%len(words)] + " ")
+		}
+		files[fmt.Sprintf("post-%04d.md", i)] = fmt.Sprintf("---\ntitle: Post %d\ndate: %s\ntags: %s\n---\nPost %d is about word%d.\n\n%s\n",
+			i, date.AddDate(0, 0, i).Format("2006-01-02"), tags[i%len(tags)], i, i, body.String())
+	}
+	return files
+}
+
+func TestExportLargeBlogMemory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("exports 2,000 posts")
+	}
+	const posts = 2000
+	blog := newTestBlog(t, syntheticPosts(posts))
+	distDir := t.TempDir()
+
+	// Collect garbage often so the heap tracks what the export holds on to.
+	defer debug.SetGCPercent(debug.SetGCPercent(10))
+	runtime.GC()
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	done := make(chan struct{})
+	peak := make(chan uint64)
+	go func() {
+		var stats runtime.MemStats
+		var max uint64
+		ticker := time.NewTicker(2 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			runtime.ReadMemStats(&stats)
+			if stats.HeapAlloc > max {
+				max = stats.HeapAlloc
+			}
+			select {
+			case <-done:
+				peak <- max
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	err := blog.Export(distDir)
+	close(done)
+	if err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	var growth uint64
+	if p := <-peak; p > before.HeapAlloc {
+		growth = p - before.HeapAlloc
+	}
+
+	var written int64
+	pages := 0
+	filepath.WalkDir(distDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		written += info.Size()
+		if strings.HasPrefix(filepath.ToSlash(path), filepath.ToSlash(distDir)+"/post/") {
+			pages++
+		}
+		return nil
+	})
+	if pages != posts {
+		t.Errorf("Expected %d post pages, got %d", posts, pages)
+	}
+	// Buffering the export would hold every file at once.
+	if growth > uint64(written)/2 {
+		t.Errorf("Expected the export to stream its %d bytes, but the heap grew by %d", written, growth)
+	}
+
+	page, err := os.ReadFile(filepath.Join(distDir, "post", "post-1234", "index.html"))
+	if err != nil || !strings.Contains(string(page), "Post 1234 is about word1234.") {
+		t.Errorf("Expected the exported post to hold its content, got %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(distDir, "search-index.json"))
+	if err != nil {
+		t.Fatalf("Failed to read the search index: %v", err)
+	}
+	var index SearchIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		t.Fatalf("Failed to parse the search index: %v", err)
+	}
+	if len(index.Posts) != posts || !contains(index.InvertedIndex["word1234"], "post-1234") {
+		t.Errorf("Expected all %d posts in the search index, got %d", posts, len(index.Posts))
+	}
+}
+
+func BenchmarkExport(b *testing.B) {
+	blog := newTestBlog(b, syntheticPosts(500))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := blog.Export(filepath.Join(b.TempDir(), "dist")); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestExportIncremental(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"first.md":  "---\ntitle: First\ndate: 2024-01-01\ntags: go\n---\nThe first post.",
+		"second.md": "---\ntitle: Second\ndate: 2024-01-02\ntags: go\n---\nThe second post.",
+		"third.md":  "---\ntitle: Third\ndate: 2024-01-03\n---\nThe third post.",
+	})
+	postFiles := func(names []string) []string {
+		var posts []string
+		for _, name := range names {
+			if strings.HasPrefix(name, "post/") {
+				posts = append(posts, name)
+			}
+		}
+		return posts
+	}
+
+	distDir := t.TempDir()
+	report, err := blog.ExportIncremental(distDir, false)
+	if err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	if len(report.Skipped) != 0 || len(postFiles(report.Written)) != 3 {
+		t.Fatalf("Expected a first export to write every file, got %+v", report)
+	}
+
+	report, err = blog.ExportIncremental(distDir, false)
+	if err != nil {
+		t.Fatalf("Failed to re-export: %v", err)
+	}
+	if len(report.Written) != 0 || len(report.Removed) != 0 {
+		t.Errorf("Expected an unchanged export to write nothing, got %+v", report)
+	}
+
+	blog.RemovePost("second")
+	if err := blog.AddMarkdown("second.md", "---\ntitle: Second\ndate: 2024-01-02\ntags: go\n---\nThe second post, revised."); err != nil {
+		t.Fatalf("Failed to update post: %v", err)
+	}
+	report, err = blog.ExportIncremental(distDir, false)
+	if err != nil {
+		t.Fatalf("Failed to re-export: %v", err)
+	}
+	if written := postFiles(report.Written); !reflect.DeepEqual(written, []string{"post/second/index.html"}) {
+		t.Errorf("Expected only the changed post to be rewritten, got %v", written)
+	}
+	page, _ := os.ReadFile(filepath.Join(distDir, "post", "second", "index.html"))
+	if !strings.Contains(string(page), "revised") {
+		t.Errorf("Expected the rewritten page to hold the new content")
+	}
+
+	if report, _ = blog.ExportIncremental(distDir, true); len(report.Skipped) != 0 {
+		t.Errorf("Expected a forced export to rewrite every file, got %+v", report)
+	}
+
+	// Files of a removed post go away with their directory.
+	blog.RemovePost("third")
+	report, err = blog.ExportIncremental(distDir, false)
+	if err != nil {
+		t.Fatalf("Failed to re-export: %v", err)
+	}
+	if !reflect.DeepEqual(report.Removed, []string{"post/third/index.html"}) {
+		t.Errorf("Expected the removed post's page to be deleted, got %v", report.Removed)
+	}
+	if _, err := os.Stat(filepath.Join(distDir, "post", "third")); !os.IsNotExist(err) {
+		t.Errorf("Expected the removed post's directory to be deleted, got %v", err)
+	}
+}
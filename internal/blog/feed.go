@@ -0,0 +1,250 @@
+package blog
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// feedItem is the format-neutral view of a post shared by the RSS, Atom
+// and JSON Feed generators.
+type feedItem struct {
+	ID      string
+	Title   string
+	URL     string
+	Date    time.Time
+	Updated time.Time
+	Tags    []string
+	Summary string // plain text
+	Content string // rendered HTML
+}
+
+// feedItems returns every post as a feed item, newest first.
+func (b *Blog) feedItems() []feedItem {
+	items := make([]feedItem, 0, len(b.postList))
+	for _, post := range b.postList {
+		url := b.postURL(post.Slug)
+		items = append(items, feedItem{
+			ID:      url,
+			Title:   post.Title,
+			URL:     url,
+			Date:    post.Date,
+			Updated: post.Updated,
+			Tags:    post.Tags,
+			Summary: post.Excerpt,
+			Content: string(post.HTMLContent),
+		})
+	}
+	return items
+}
+
+// feedUpdated returns the latest update time of any item, or the zero
+// time when there are none.
+func feedUpdated(items []feedItem) time.Time {
+	var updated time.Time
+	for _, item := range items {
+		if item.Updated.After(updated) {
+			updated = item.Updated
+		}
+	}
+	return updated
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	HubLinks      []rssHub  `xml:"http://www.w3.org/2005/Atom link"`
+	Items         []rssItem `xml:"item"`
+}
+
+// rssHub is an atom:link, the way RSS feeds point to their WebSub hub.
+type rssHub struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Categories  []string `xml:"category"`
+	Description string   `xml:"description"`
+	Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+// rssXML renders the posts as an RSS 2.0 feed.
+func (b *Blog) rssXML() []byte {
+	items := b.feedItems()
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       b.Config.BlogName,
+			Link:        b.Config.BaseURL + "/",
+			Description: b.Config.Introduction,
+		},
+	}
+	if hub := b.Config.WebSubHub; hub != "" {
+		feed.Channel.HubLinks = []rssHub{
+			{Href: hub, Rel: "hub"},
+			{Href: b.Config.BaseURL + "/rss.xml", Rel: "self"},
+		}
+	}
+	if updated := feedUpdated(items); !updated.IsZero() {
+		feed.Channel.LastBuildDate = updated.Format(time.RFC1123Z)
+	}
+	for _, item := range items {
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       item.Title,
+			Link:        item.URL,
+			GUID:        rssGUID{Value: item.ID, IsPermaLink: true},
+			PubDate:     item.Date.Format(time.RFC1123Z),
+			Categories:  item.Tags,
+			Description: item.Summary,
+			Content:     item.Content,
+		})
+	}
+	return marshalFeed(feed)
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Link       atomLink       `xml:"link"`
+	Categories []atomCategory `xml:"category"`
+	Summary    string         `xml:"summary,omitempty"`
+	Content    atomContent    `xml:"content"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// atomXML renders the posts as an Atom 1.0 feed.
+func (b *Blog) atomXML() []byte {
+	items := b.feedItems()
+	feed := atomFeed{
+		ID:      b.Config.BaseURL + "/",
+		Title:   b.Config.BlogName,
+		Updated: feedUpdated(items).Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: b.Config.BaseURL + "/atom.xml", Rel: "self"},
+			{Href: b.Config.BaseURL + "/"},
+		},
+		Author: atomAuthor{Name: b.Config.BlogName},
+	}
+	if hub := b.Config.WebSubHub; hub != "" {
+		feed.Links = append(feed.Links, atomLink{Href: hub, Rel: "hub"})
+	}
+	for _, item := range items {
+		entry := atomEntry{
+			ID:        item.ID,
+			Title:     item.Title,
+			Published: item.Date.Format(time.RFC3339),
+			Updated:   item.Updated.Format(time.RFC3339),
+			Link:      atomLink{Href: item.URL},
+			Summary:   item.Summary,
+			Content:   atomContent{Type: "html", Value: item.Content},
+		}
+		for _, tag := range item.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return marshalFeed(feed)
+}
+
+const jsonFeedVersion = "https://jsonfeed.org/version/1.1"
+
+// JSONFeed is a JSON Feed 1.1 document.
+type JSONFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Description string         `json:"description,omitempty"`
+	Items       []JSONFeedItem `json:"items"`
+}
+
+// JSONFeedItem is a single post in a JSONFeed.
+type JSONFeedItem struct {
+	ID            string   `json:"id"`
+	URL           string   `json:"url"`
+	Title         string   `json:"title"`
+	ContentHTML   string   `json:"content_html"`
+	Summary       string   `json:"summary,omitempty"`
+	DatePublished string   `json:"date_published"`
+	DateModified  string   `json:"date_modified,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+}
+
+// jsonFeed builds the posts as a JSON Feed 1.1 document.
+func (b *Blog) jsonFeed() JSONFeed {
+	feed := JSONFeed{
+		Version:     jsonFeedVersion,
+		Title:       b.Config.BlogName,
+		HomePageURL: b.Config.BaseURL + "/",
+		FeedURL:     b.Config.BaseURL + "/feed.json",
+		Description: b.Config.Introduction,
+		Items:       []JSONFeedItem{},
+	}
+	for _, item := range b.feedItems() {
+		feed.Items = append(feed.Items, JSONFeedItem{
+			ID:            item.ID,
+			URL:           item.URL,
+			Title:         item.Title,
+			ContentHTML:   item.Content,
+			Summary:       item.Summary,
+			DatePublished: item.Date.Format(time.RFC3339),
+			DateModified:  item.Updated.Format(time.RFC3339),
+			Tags:          item.Tags,
+		})
+	}
+	return feed
+}
+
+func marshalFeed(feed interface{}) []byte {
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil
+	}
+	return append([]byte(xml.Header), data...)
+}
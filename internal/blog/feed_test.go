@@ -0,0 +1,128 @@
+package blog
+
+import (
+	"embed"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAtomFeed(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"old.md": "---\ntitle: Old\ndate: 2024-01-01\n---\nFirst post.",
+		"new.md": "---\ntitle: New\ndate: 2024-03-05\ntags: go\n---\nSecond **post**.",
+	})
+	blog.Config.BaseURL = "https://example.com"
+
+	rec := httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/atom.xml", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/atom+xml") {
+		t.Errorf("Expected Atom content type, got %q", ct)
+	}
+
+	var feed atomFeed
+	if err := xml.Unmarshal(rec.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("Failed to parse Atom feed: %v", err)
+	}
+	if feed.ID == "" || feed.Title == "" || feed.Author.Name == "" {
+		t.Errorf("Expected feed id, title and author, got %+v", feed)
+	}
+	if feed.Updated != "2024-03-05T00:00:00Z" {
+		t.Errorf("Expected feed updated to be the newest post date, got %q", feed.Updated)
+	}
+	if len(feed.Entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(feed.Entries))
+	}
+
+	entry := feed.Entries[0]
+	if entry.ID != "https://example.com/post/new/" || entry.Link.Href != entry.ID {
+		t.Errorf("Expected entry id and link to be the post URL, got %q and %q", entry.ID, entry.Link.Href)
+	}
+	if entry.Title != "New" || entry.Updated != "2024-03-05T00:00:00Z" {
+		t.Errorf("Unexpected entry title or updated: %+v", entry)
+	}
+	if entry.Content.Type != "html" || !strings.Contains(entry.Content.Value, "<strong>post</strong>") {
+		t.Errorf("Expected HTML content, got %+v", entry.Content)
+	}
+}
+
+func TestRSSFeedSharesItems(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHello.",
+	})
+
+	var feed rssFeed
+	if err := xml.Unmarshal(blog.rssXML(), &feed); err != nil {
+		t.Fatalf("Failed to parse RSS feed: %v", err)
+	}
+	items := blog.feedItems()
+	if len(feed.Channel.Items) != len(items) || feed.Channel.Items[0].Link != items[0].URL {
+		t.Errorf("Expected RSS items to match feedItems, got %+v", feed.Channel.Items)
+	}
+}
+
+func TestJSONFeed(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"old.md": "---\ntitle: Old\ndate: 2024-01-01\n---\nFirst post.",
+		"new.md": "---\ntitle: New\ndate: 2024-03-05\n---\nSecond post.",
+	})
+
+	rec := httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feed.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+
+	var feed JSONFeed
+	if err := json.Unmarshal(rec.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("Failed to parse JSON Feed: %v", err)
+	}
+	if feed.Version != "https://jsonfeed.org/version/1.1" {
+		t.Errorf("Expected JSON Feed 1.1 version, got %q", feed.Version)
+	}
+	if len(feed.Items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(feed.Items))
+	}
+	if feed.Items[0].Title != "New" || feed.Items[0].DatePublished != "2024-03-05T00:00:00Z" {
+		t.Errorf("Unexpected first item: %+v", feed.Items[0])
+	}
+}
+
+func TestRSSFeedUsesTimezone(t *testing.T) {
+	root := os.DirFS("../..")
+	blog, _ := NewBlogWithConfig(Config{BlogName: "Test", Timezone: "Europe/Amsterdam"}, root, root, embed.FS{})
+	blog.blogFS = fstest.MapFS{
+		"blog/winter.md": {Data: []byte("---\ntitle: Winter\ndate: 2024-01-27\n---\nCold.")},
+		"blog/summer.md": {Data: []byte("---\ntitle: Summer\ndate: 2024-07-01\n---\nWarm.")},
+	}
+	if err := blog.LoadPosts(); err != nil {
+		t.Fatalf("Failed to load posts: %v", err)
+	}
+
+	rss := string(blog.rssXML())
+	for _, want := range []string{
+		"<pubDate>Sat, 27 Jan 2024 00:00:00 +0100</pubDate>",
+		"<pubDate>Mon, 01 Jul 2024 00:00:00 +0200</pubDate>",
+	} {
+		if !strings.Contains(rss, want) {
+			t.Errorf("Expected %s in the feed, got %s", want, rss)
+		}
+	}
+}
+
+func TestInvalidTimezoneFallsBackToUTC(t *testing.T) {
+	if got := applyConfigDefaults(Config{Timezone: "Mars/Olympus_Mons"}).Timezone; got != "UTC" {
+		t.Errorf("Expected an unknown timezone to fall back to UTC, got %q", got)
+	}
+	if got := applyConfigDefaults(Config{}).Timezone; got != "UTC" {
+		t.Errorf("Expected UTC by default, got %q", got)
+	}
+}
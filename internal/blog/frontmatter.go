@@ -0,0 +1,147 @@
+package blog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// frontmatter holds the fields a post sets in its frontmatter. YAML, TOML
+// and JSON frontmatter are all decoded into it; other keys, including
+// Hugo's [params] table, are ignored.
+type frontmatter struct {
+	Title         string          `yaml:"title" toml:"title" json:"title"`
+	Date          frontmatterDate `yaml:"date" toml:"date" json:"date"`
+	Updated       frontmatterDate `yaml:"updated" toml:"updated" json:"updated"`
+	Tags          frontmatterTags `yaml:"tags" toml:"tags" json:"tags"`
+	Slug          string          `yaml:"slug" toml:"slug" json:"slug"`
+	Draft         bool            `yaml:"draft" toml:"draft" json:"draft"`
+	Featured      bool            `yaml:"featured" toml:"featured" json:"featured"`
+	FeaturedOrder frontmatterInt  `yaml:"featured_order" toml:"featured_order" json:"featured_order"` // implies Featured
+	Weight        frontmatterInt  `yaml:"weight" toml:"weight" json:"weight"`
+	Image         string          `yaml:"image" toml:"image" json:"image"`
+	Category      string          `yaml:"category" toml:"category" json:"category"`
+	Series        string          `yaml:"series" toml:"series" json:"series"`
+	OGType        string          `yaml:"og_type" toml:"og_type" json:"og_type"`
+}
+
+// frontmatterDate is a date as written in frontmatter. It is parsed with
+// parseFrontmatterDate once the blog's time zone is known.
+type frontmatterDate string
+
+// UnmarshalTOML keeps TOML's typed dates as text. Dates and date-times
+// without an offset stay without one, so they are read in Config.Timezone
+// like YAML dates.
+func (d *frontmatterDate) UnmarshalTOML(v interface{}) error {
+	switch v := v.(type) {
+	case string:
+		*d = frontmatterDate(v)
+	case time.Time:
+		// The toml package marks local values with these zone names.
+		switch v.Location().String() {
+		case "date-local":
+			*d = frontmatterDate(v.Format(time.DateOnly))
+		case "datetime-local":
+			*d = frontmatterDate(v.Format(localDateTimeLayout))
+		case "time-local":
+			return errors.New("a time of day is not a date")
+		default:
+			*d = frontmatterDate(v.Format(time.RFC3339Nano))
+		}
+	default:
+		return fmt.Errorf("a date must be a date or a string, not %T", v)
+	}
+	return nil
+}
+
+// frontmatterInt is a number as written in frontmatter. parsePost warns
+// about values that aren't integers and ignores them, rather than failing
+// the whole post.
+type frontmatterInt string
+
+func (n *frontmatterInt) UnmarshalTOML(v interface{}) error {
+	*n = frontmatterInt(fmt.Sprint(v))
+	return nil
+}
+
+func (n *frontmatterInt) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v != nil {
+		*n = frontmatterInt(fmt.Sprint(v))
+	}
+	return nil
+}
+
+// frontmatterTags is a post's tags. YAML frontmatter may give them as a
+// list or, as older posts do, a comma-separated string.
+type frontmatterTags []string
+
+func (t *frontmatterTags) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*t = strings.Split(node.Value, ",")
+		return nil
+	}
+	var tags []string
+	if err := node.Decode(&tags); err != nil {
+		return err
+	}
+	*t = tags
+	return nil
+}
+
+// splitJSONFrontmatter decodes the JSON object content starts with as the
+// frontmatter. The body is whatever follows the object.
+func splitJSONFrontmatter(content string) (fm frontmatter, body string, err error) {
+	dec := json.NewDecoder(strings.NewReader(content))
+	if err := dec.Decode(&fm); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return fm, "", fmt.Errorf("%w: missing closing }", errUnterminatedFrontmatter)
+		}
+		return fm, "", fmt.Errorf("invalid JSON frontmatter: %w", err)
+	}
+	return fm, content[dec.InputOffset():], nil
+}
+
+// decodeFrontmatter decodes the YAML or TOML between the fence lines.
+func decodeFrontmatter(fence, text string) (fm frontmatter, err error) {
+	if fence == "+++" {
+		if _, err := toml.Decode(text, &fm); err != nil {
+			var parseErr toml.ParseError
+			if errors.As(err, &parseErr) {
+				// Line numbers count the opening +++ line.
+				return fm, fmt.Errorf("invalid TOML frontmatter on line %d: %s", parseErr.Position.Line+1, parseErr.Message)
+			}
+			return fm, fmt.Errorf("invalid TOML frontmatter: %w", err)
+		}
+		return fm, nil
+	}
+	if err := yaml.Unmarshal([]byte(text), &fm); err != nil {
+		return fm, fmt.Errorf("invalid YAML frontmatter: %w", err)
+	}
+	return fm, nil
+}
+
+// localDateTimeLayout is a date and time of day without an offset, as
+// TOML writes local date-times.
+const localDateTimeLayout = "2006-01-02T15:04:05"
+
+// parseFrontmatterDate parses a frontmatter date: a plain date, or a date
+// and time with or without an offset. Values without an offset are read
+// in loc.
+func parseFrontmatterDate(s string, loc *time.Location) (time.Time, error) {
+	for _, layout := range []string{time.DateOnly, localDateTimeLayout} {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Parse(time.RFC3339, s)
+}
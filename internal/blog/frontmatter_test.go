@@ -0,0 +1,125 @@
+package blog
+
+import (
+	"embed"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePostFrontmatterFormats(t *testing.T) {
+	blog, _ := NewBlog(embed.FS{}, embed.FS{}, embed.FS{})
+	sources := map[string]string{
+		"yaml": "---\ntitle: Same Post\ndate: 2024-01-27\nupdated: 2024-02-03\n" +
+			"tags: [go, web dev]\nslug: same-post\nseries: Basics\nweight: 2\nfeatured_order: 1\n" +
+			"image: /static/same.png\nog_type: website\n---\nThe body.\n",
+		"toml": "+++\n# Written for Hugo\ntitle = \"Same Post\"\ndate = 2024-01-27\nupdated = '2024-02-03'\n" +
+			"tags = [\n  \"go\", # the language\n  \"web dev\",\n]\nslug = \"same-post\"\nseries = \"Basics\"\n" +
+			"weight = 2\nfeatured_order = 1 # pinned first\nimage = \"/static/same.png\"\nog_type = \"website\"\n" +
+			"[params]\ntitle = \"Ignored\"\n+++\nThe body.\n",
+		"json": "{\n  \"title\": \"Same Post\",\n  \"date\": \"2024-01-27\",\n  \"updated\": \"2024-02-03\",\n" +
+			"  \"tags\": [\"go\", \"web dev\"],\n  \"slug\": \"same-post\",\n  \"series\": \"Basics\",\n" +
+			"  \"weight\": 2,\n  \"featured_order\": 1,\n  \"image\": \"/static/same.png\",\n" +
+			"  \"og_type\": \"website\",\n  \"params\": {\"title\": \"Ignored\"}\n}\nThe body.\n",
+	}
+
+	posts := make(map[string]*Post)
+	for format, source := range sources {
+		post, err := blog.parsePost(format+".md", source)
+		if err != nil {
+			t.Fatalf("Failed to parse %s frontmatter: %v", format, err)
+		}
+		posts[format] = post
+	}
+
+	want := posts["yaml"]
+	if want.Title != "Same Post" || want.Slug != "same-post" || !reflect.DeepEqual(want.Tags, []string{"go", "web dev"}) ||
+		!want.Featured || want.Weight != 2 || want.OGType != "website" {
+		t.Fatalf("Unexpected YAML post %+v", want)
+	}
+	for _, format := range []string{"toml", "json"} {
+		if got := posts[format]; !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %s frontmatter to give the same post as YAML:\ngot  %+v\nwant %+v", format, got, want)
+		}
+	}
+}
+
+func TestSplitFrontmatterErrors(t *testing.T) {
+	for _, test := range []struct {
+		content string
+		want    string
+	}{
+		{"+++\ntitle = \"Open\"\n", "unterminated frontmatter: missing closing +++ line"},
+		{"{\"title\": \"Open\"\n", "unterminated frontmatter: missing closing }"},
+		{"+++\ntitle: Wrong\n+++\n", "invalid TOML frontmatter on line 2: expected '.' or '='"},
+		{"+++\ntitle = \"Open\n+++\n", "invalid TOML frontmatter on line 2: strings cannot contain newlines"},
+		{"+++\ntitle = \"Open\"\ntags = [\"a\", \"b\"\n+++\n", "invalid TOML frontmatter on line 3: expected a comma"},
+		{"+++\ntitle = \"A\" \"B\"\n+++\n", "invalid TOML frontmatter on line 2: expected a top-level item to end"},
+		{"{\"title\": [1,}\n", "invalid JSON frontmatter"},
+	} {
+		_, _, err := splitFrontmatter(test.content)
+		if err == nil || !strings.HasPrefix(err.Error(), test.want) {
+			t.Errorf("splitFrontmatter(%q) = %v, want %q", test.content, err, test.want)
+		}
+	}
+
+	if _, _, err := splitFrontmatter("+++\n+++\n"); err != nil {
+		t.Errorf("Expected empty TOML frontmatter to be accepted, got %v", err)
+	}
+	if _, _, err := splitFrontmatter("+++\ntitle = \"Open\"\n"); !errors.Is(err, errUnterminatedFrontmatter) {
+		t.Errorf("Expected an unterminated-frontmatter error, got %v", err)
+	}
+}
+
+func TestParsePostTOMLDateTimes(t *testing.T) {
+	blog, _ := NewBlogWithConfig(Config{Timezone: "Europe/Istanbul"}, embed.FS{}, embed.FS{}, embed.FS{})
+	istanbul, _ := time.LoadLocation("Europe/Istanbul")
+	for _, test := range []struct {
+		date string
+		want time.Time
+	}{
+		{"2024-01-02T03:04:05Z", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{"2024-01-02T03:04:05+03:00", time.Date(2024, 1, 2, 0, 4, 5, 0, time.UTC)},
+		{"2024-01-02T03:04:05", time.Date(2024, 1, 2, 3, 4, 5, 0, istanbul)},
+		{"2024-01-02", time.Date(2024, 1, 2, 0, 0, 0, 0, istanbul)},
+	} {
+		post, err := blog.parsePost("dated.md", "+++\ntitle = \"Dated\"\ndate = "+test.date+"\nupdated = "+test.date+"\n+++\nBody.")
+		if err != nil {
+			t.Fatalf("Failed to parse date %s: %v", test.date, err)
+		}
+		if !post.Date.Equal(test.want) || !post.Updated.Equal(test.want) {
+			t.Errorf("date = %s: expected %v, got date %v and updated %v", test.date, test.want, post.Date, post.Updated)
+		}
+	}
+}
+
+func TestParsePostFrontmatterValues(t *testing.T) {
+	blog, _ := NewBlog(embed.FS{}, embed.FS{}, embed.FS{})
+	for _, test := range []struct {
+		format, source, series string
+	}{
+		{"yaml", "---\ntitle: \"Go: A Tour\"\nseries: >-\n  Notes on\n  Go\ncategory: 'a: b'\n" +
+			"params:\n  title: Ignored\n  tags: [ignored]\ntags:\n  - go\n---\nBody.", "Notes on Go"},
+		{"toml", "+++\ntitle = \"\"\"\nGo: A\nTour\"\"\"\nseries = \"Notes on\\nGo\"\ncategory = 'a: b'\ntags = [\"go\"]\n" +
+			"[params]\ntitle = \"Ignored\"\ntags = [\"ignored\"]\n[params.nested]\ndraft = true\n+++\nBody.", "Notes on\nGo"},
+		{"json", "{\"title\": \"Go: A\\nTour\", \"series\": \"Notes on\\nGo\", \"category\": \"a: b\", \"tags\": [\"go\"]," +
+			" \"params\": {\"title\": \"Ignored\", \"nested\": {\"draft\": true}}}\nBody.", "Notes on\nGo"},
+	} {
+		post, err := blog.parsePost("values.md", test.source)
+		if err != nil {
+			t.Fatalf("Failed to parse %s frontmatter: %v", test.format, err)
+		}
+		if post.Title != "Go: A Tour" || post.Category != "a: b" || !reflect.DeepEqual(post.Tags, []string{"go"}) || post.Draft {
+			t.Errorf("Unexpected %s post %+v", test.format, post)
+		}
+		if post.Series != test.series {
+			t.Errorf("Expected the %s series %q, got %q", test.format, test.series, post.Series)
+		}
+	}
+
+	if _, err := blog.parsePost("bad.md", "---\ntitle: [unclosed\n---\nBody."); err == nil || !strings.HasPrefix(err.Error(), "invalid YAML frontmatter") {
+		t.Errorf("Expected invalid YAML to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package blog
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// templateFuncs are the helpers available to every page template.
+var templateFuncs = template.FuncMap{
+	"asset":      assetPath,
+	"formatDate": formatDate,
+	"truncate":   truncate,
+	"slugify":    slugify,
+}
+
+// blogTemplateFuncs adds to templateFuncs the helpers that depend on a
+// blog's config: humanizeDate, e.g. {{humanizeDate .Date}}, which counts
+// days in loc and switches to an absolute date after relativeDays.
+func blogTemplateFuncs(relativeDays int, loc *time.Location) template.FuncMap {
+	funcs := template.FuncMap{
+		"humanizeDate": func(t time.Time) string {
+			return humanizeDate(t, time.Now(), relativeDays, loc)
+		},
+	}
+	for name, fn := range templateFuncs {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// humanizeDate describes t relative to now in calendar days of loc:
+// "today", "yesterday" or "3 days ago". Dates more than relativeDays ago,
+// and dates in the future, are written out instead.
+func humanizeDate(t, now time.Time, relativeDays int, loc *time.Location) string {
+	t, now = t.In(loc), now.In(loc)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	switch days := int(today.Sub(day).Hours() / 24); {
+	case days < 0 || days > relativeDays:
+		return t.Format("January 2, 2006")
+	case days == 0:
+		return "today"
+	case days == 1:
+		return "yesterday"
+	default:
+		return fmt.Sprintf("%d days ago", days)
+	}
+}
+
+// formatDate formats t with a Go time layout, e.g. {{formatDate .Date "Jan 2, 2006"}}.
+func formatDate(t time.Time, layout string) string {
+	return t.Format(layout)
+}
+
+// truncate shortens s to at most n characters at a word boundary, ending
+// with an ellipsis when anything was cut.
+func truncate(s string, n int) string {
+	return excerpt(s, n)
+}
+
+// slugify lowercases s and joins its runs of letters and digits with
+// hyphens, so "Go & Web Dev" becomes "go-web-dev".
+func slugify(s string) string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, "-")
+}
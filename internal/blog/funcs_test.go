@@ -0,0 +1,66 @@
+package blog
+
+import (
+	"bytes"
+	"html/template"
+	"testing"
+	"time"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	tmpl := template.Must(template.New("test").Funcs(templateFuncs).Parse(
+		`{{formatDate .Date "Jan 2, 2006"}}|{{truncate .Text 10}}|{{slugify .Title}}`))
+
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, map[string]interface{}{
+		"Date":  time.Date(2024, time.January, 27, 0, 0, 0, 0, time.UTC),
+		"Text":  "one two three four",
+		"Title": "Go & Web Dev: Part 2",
+	})
+	if err != nil {
+		t.Fatalf("Failed to execute template: %v", err)
+	}
+
+	want := "Jan 27, 2024|one two…|go-web-dev-part-2"
+	if got := buf.String(); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestHumanizeDate(t *testing.T) {
+	amsterdam, err := time.LoadLocation("Europe/Amsterdam")
+	if err != nil {
+		t.Skip("No timezone data:", err)
+	}
+	now := time.Date(2024, time.March, 15, 9, 0, 0, 0, amsterdam)
+
+	tests := []struct {
+		date time.Time
+		want string
+	}{
+		{time.Date(2024, time.March, 15, 0, 0, 0, 0, amsterdam), "today"},
+		// Still March 15 in Amsterdam, though not in UTC.
+		{time.Date(2024, time.March, 14, 23, 30, 0, 0, time.UTC), "today"},
+		{time.Date(2024, time.March, 14, 22, 0, 0, 0, amsterdam), "yesterday"},
+		{time.Date(2024, time.March, 8, 0, 0, 0, 0, amsterdam), "7 days ago"},
+		{time.Date(2024, time.February, 14, 0, 0, 0, 0, amsterdam), "30 days ago"},
+		{time.Date(2024, time.February, 13, 0, 0, 0, 0, amsterdam), "February 13, 2024"},
+		{time.Date(2024, time.March, 16, 0, 0, 0, 0, amsterdam), "March 16, 2024"},
+	}
+	for _, tt := range tests {
+		if got := humanizeDate(tt.date, now, 30, amsterdam); got != tt.want {
+			t.Errorf("humanizeDate(%v) = %q, want %q", tt.date, got, tt.want)
+		}
+	}
+
+	if got := humanizeDate(tests[3].date, now, 5, amsterdam); got != "March 8, 2024" {
+		t.Errorf("Expected a week-old date past a 5 day threshold to be written out, got %q", got)
+	}
+
+	funcs := blogTemplateFuncs(defaultRelativeDateDays, time.UTC)
+	tmpl := template.Must(template.New("test").Funcs(funcs).Parse(`{{humanizeDate .}}`))
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, time.Now()); err != nil || buf.String() != "today" {
+		t.Errorf("Expected the template helper to describe now as today, got %q, %v", buf.String(), err)
+	}
+}
@@ -0,0 +1,79 @@
+package blog
+
+import (
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// kindFigure is the node kind of figure.
+var kindFigure = ast.NewNodeKind("Figure")
+
+// figure is a block holding a single image captioned by its alt text.
+type figure struct {
+	ast.BaseBlock
+	caption string
+}
+
+func (n *figure) Kind() ast.NodeKind { return kindFigure }
+
+func (n *figure) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Caption": n.caption}, nil)
+}
+
+// lazyImages marks every image for lazy loading and async decoding. An
+// image alone in its paragraph with alt text becomes a figure whose
+// caption is the alt text. Working on the AST rather than the rendered
+// HTML leaves escaping and the other attributes to goldmark.
+type lazyImages struct{}
+
+func (lazyImages) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+
+	var images []*ast.Image
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if img, ok := n.(*ast.Image); ok && entering {
+			images = append(images, img)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	// The tree is only modified once the walk is done.
+	for _, img := range images {
+		img.SetAttributeString("loading", []byte("lazy"))
+		img.SetAttributeString("decoding", []byte("async"))
+
+		para, ok := img.Parent().(*ast.Paragraph)
+		if !ok || para.ChildCount() != 1 {
+			continue
+		}
+		caption := nodeText(img, source)
+		if caption == "" {
+			continue
+		}
+		fig := &figure{caption: caption}
+		para.Parent().ReplaceChild(para.Parent(), para, fig)
+		fig.AppendChild(fig, img)
+	}
+}
+
+// figureRenderer renders figure nodes; the image inside is left to the
+// default renderer.
+type figureRenderer struct{}
+
+func (figureRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindFigure, renderFigure)
+}
+
+func renderFigure(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		w.WriteString("<figure>")
+		return ast.WalkContinue, nil
+	}
+	w.WriteString("<figcaption>")
+	w.Write(util.EscapeHTML([]byte(n.(*figure).caption)))
+	w.WriteString("</figcaption></figure>\n")
+	return ast.WalkContinue, nil
+}
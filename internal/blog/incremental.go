@@ -0,0 +1,88 @@
+package blog
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// exportManifest is what an export records in its marker file so the next
+// one can tell which files changed: the SHA-256 of every file it wrote,
+// by slash-separated path.
+type exportManifest struct {
+	Precompress bool              `json:"precompress"`
+	Files       map[string]string `json:"files"`
+}
+
+// readExportManifest returns the manifest of the export in distDir. Exports
+// made before manifests existed, or by a different precompress setting,
+// have none that can be trusted, and ok is false.
+func readExportManifest(distDir string, precompress bool) (manifest exportManifest, ok bool) {
+	data, err := os.ReadFile(filepath.Join(distDir, exportMarker))
+	if err != nil || json.Unmarshal(data, &manifest) != nil || manifest.Files == nil {
+		return exportManifest{}, false
+	}
+	return manifest, manifest.Precompress == precompress
+}
+
+func writeExportManifest(distDir string, manifest exportManifest) error {
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(distDir, exportMarker), data, 0644)
+}
+
+// ExportReport lists what an export did to each file, by slash-separated
+// path: rewritten, left alone because it was unchanged, or removed because
+// the site no longer has it.
+type ExportReport struct {
+	Written []string
+	Skipped []string
+	Removed []string
+}
+
+func (r *ExportReport) sort() {
+	sort.Strings(r.Written)
+	sort.Strings(r.Skipped)
+	sort.Strings(r.Removed)
+}
+
+// removeStaleFiles deletes every file in distDir that the current export
+// did not produce, such as pages of removed posts, and the directories
+// left empty, so the directory holds the same files a fresh export would.
+func removeStaleFiles(distDir string, current map[string]string) []string {
+	var removed, dirs []string
+	filepath.WalkDir(distDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.IsDir() {
+			dirs = append(dirs, path)
+			return nil
+		}
+		name, err := filepath.Rel(distDir, path)
+		if err != nil || name == exportMarker {
+			return nil
+		}
+		name = filepath.ToSlash(name)
+		if _, ok := current[name]; ok {
+			return nil
+		}
+		if _, ok := current[strings.TrimSuffix(name, ".gz")]; ok && strings.HasSuffix(name, ".gz") {
+			return nil
+		}
+		if os.Remove(path) == nil {
+			removed = append(removed, name)
+		}
+		return nil
+	})
+	// Deepest first, so parents are emptied before they are tried.
+	for i := len(dirs) - 1; i > 0; i-- {
+		os.Remove(dirs[i])
+	}
+	return removed
+}
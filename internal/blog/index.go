@@ -0,0 +1,164 @@
+package blog
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+)
+
+// searchIndexCache holds the marshalled search index served at
+// /search-index.json, the autocomplete suggestions, and recent search
+// results, until the posts or the index change.
+type searchIndexCache struct {
+	mu      sync.Mutex
+	data    []byte
+	etag    string
+	modTime time.Time
+
+	suggestions *suggestionIndex
+	results     map[string]cachedResults // by cacheKey(query)
+}
+
+// cachedResults is what searchWithFallback returned for a query.
+type cachedResults struct {
+	posts     []*Post
+	broadened bool
+}
+
+// maxCachedSearches bounds the queries whose results are cached. When it
+// is reached the cache starts over, so random queries can't grow it.
+const maxCachedSearches = 1000
+
+func newInvertedIndex() *InvertedIndex {
+	return &InvertedIndex{
+		index:  make(map[string][]string),
+		titles: make(map[string][]string),
+		terms:  make(map[string][]string),
+	}
+}
+
+// indexPost adds post to the search index, replacing any postings it
+// already had, without touching other posts.
+func (b *Blog) indexPost(post *Post) {
+	b.invertedIndex.mu.Lock()
+	defer b.invertedIndex.mu.Unlock()
+
+	b.invertedIndex.remove(post.ID)
+	b.invertedIndex.add(post)
+}
+
+// unindexPost removes the post with the given ID from the search index.
+func (b *Blog) unindexPost(id string) {
+	b.invertedIndex.mu.Lock()
+	defer b.invertedIndex.mu.Unlock()
+
+	b.invertedIndex.remove(id)
+}
+
+// add records post under every word of its title and content, and
+// separately under the words of its title so search can weight them.
+// Posting lists are kept sorted by post ID, so the index does not depend
+// on the order posts were added in. The caller must hold the write lock.
+func (idx *InvertedIndex) add(post *Post) {
+	var terms []string
+	for _, word := range tokenize(post.Title + " " + post.Content) {
+		word = foldCase(word)
+		if insertPosting(idx.index, word, post.ID) {
+			terms = append(terms, word)
+		}
+	}
+	for _, word := range tokenize(post.Title) {
+		insertPosting(idx.titles, foldCase(word), post.ID)
+	}
+	idx.terms[post.ID] = terms
+}
+
+// indexTitles rebuilds only the title postings, for an index loaded by
+// LoadPrebuiltIndex: the export does not record which words were in
+// titles, and tokenizing titles is cheap next to the content it saves.
+func (b *Blog) indexTitles() {
+	b.invertedIndex.mu.Lock()
+	defer b.invertedIndex.mu.Unlock()
+
+	b.invertedIndex.titles = make(map[string][]string)
+	for _, post := range b.posts {
+		for _, word := range tokenize(post.Title) {
+			insertPosting(b.invertedIndex.titles, foldCase(word), post.ID)
+		}
+	}
+}
+
+// insertPosting adds id to the sorted posting list of word, reporting
+// whether it was missing.
+func insertPosting(postings map[string][]string, word, id string) bool {
+	ids := postings[word]
+	i := sort.SearchStrings(ids, id)
+	if i < len(ids) && ids[i] == id {
+		return false
+	}
+	ids = append(ids, "")
+	copy(ids[i+1:], ids[i:])
+	ids[i] = id
+	postings[word] = ids
+	return true
+}
+
+// remove drops id from the postings of the words it was indexed under,
+// deleting words left without postings. The caller must hold the write
+// lock.
+func (idx *InvertedIndex) remove(id string) {
+	terms := idx.terms[id]
+	delete(idx.terms, id)
+
+	// Title words are also indexed words, so terms covers both.
+	for _, word := range terms {
+		removePosting(idx.index, word, id)
+		removePosting(idx.titles, word, id)
+	}
+}
+
+// removePosting drops id from the posting list of word, deleting the word
+// when no postings are left.
+func removePosting(postings map[string][]string, word, id string) {
+	ids, ok := postings[word]
+	if !ok {
+		return
+	}
+	for i, postID := range ids {
+		if postID == id {
+			ids = append(ids[:i:i], ids[i+1:]...)
+			break
+		}
+	}
+	if len(ids) == 0 {
+		delete(postings, word)
+	} else {
+		postings[word] = ids
+	}
+}
+
+// searchIndexJSON returns the marshalled search index with its ETag and the
+// time it was built, building it if the cache was invalidated.
+func (b *Blog) searchIndexJSON() ([]byte, string, time.Time) {
+	c := &b.indexCache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.data == nil {
+		c.data, _ = json.Marshal(b.NewSearchIndex())
+		c.etag = `"` + contentHash(c.data) + `"`
+		c.modTime = time.Now()
+	}
+	return c.data, c.etag, c.modTime
+}
+
+// invalidateSearchIndex drops the cached search index after posts or the
+// index changed. It must not be called with the index lock held.
+func (b *Blog) invalidateSearchIndex() {
+	b.indexCache.mu.Lock()
+	b.indexCache.data = nil
+	b.indexCache.suggestions = nil
+	b.indexCache.results = nil
+	b.indexCache.mu.Unlock()
+}
@@ -0,0 +1,91 @@
+package blog
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// normalizedIndex copies the inverted index. Posting lists are kept
+// sorted, so indexes built in any order compare equal.
+func normalizedIndex(b *Blog) map[string][]string {
+	b.invertedIndex.mu.RLock()
+	defer b.invertedIndex.mu.RUnlock()
+
+	index := make(map[string][]string, len(b.invertedIndex.index))
+	for word, ids := range b.invertedIndex.index {
+		index[word] = append([]string(nil), ids...)
+	}
+	return index
+}
+
+func TestIncrementalIndexMatchesRebuild(t *testing.T) {
+	files := map[string]string{
+		"go.md":    "---\ntitle: Learning Go\ndate: 2024-01-01\n---\nNotes on programming in Go.",
+		"pasta.md": "---\ntitle: Cooking Pasta\ndate: 2024-01-02\n---\nNotes on sauce.",
+		"rome.md":  "---\ntitle: Rome\ndate: 2024-01-03\n---\nPasta in Rome.",
+	}
+
+	incremental, _ := NewBlog(embed.FS{}, embed.FS{}, embed.FS{})
+	for name, content := range files {
+		if err := incremental.AddMarkdown(name, content); err != nil {
+			t.Fatalf("Failed to add %s: %v", name, err)
+		}
+	}
+	full := newTestBlog(t, files)
+	if got, want := normalizedIndex(incremental), normalizedIndex(full); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected incremental index to match a rebuild:\n got %v\nwant %v", got, want)
+	}
+
+	if !incremental.RemovePost("pasta") {
+		t.Fatalf("Expected RemovePost to find 'pasta'")
+	}
+	delete(files, "pasta.md")
+	full = newTestBlog(t, files)
+	if got, want := normalizedIndex(incremental), normalizedIndex(full); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected index after removal to match a rebuild:\n got %v\nwant %v", got, want)
+	}
+	if _, ok := incremental.invertedIndex.index["sauce"]; ok {
+		t.Errorf("Expected words only used by the removed post to be dropped")
+	}
+	if incremental.RemovePost("pasta") {
+		t.Errorf("Expected a second RemovePost to report a missing post")
+	}
+
+	// Re-indexing a post replaces its old postings.
+	post := incremental.posts["rome"]
+	post.Content = "Gelato in Rome."
+	incremental.indexPost(post)
+	if ids := incremental.invertedIndex.index["pasta"]; len(ids) != 0 {
+		t.Errorf("Expected stale postings to be replaced, got %v", ids)
+	}
+	if ids := incremental.invertedIndex.index["gelato"]; len(ids) != 1 || ids[0] != "rome" {
+		t.Errorf("Expected new postings for 'gelato', got %v", ids)
+	}
+}
+
+func TestSearchIndexIsDeterministic(t *testing.T) {
+	files := make(map[string]string)
+	for i := 1; i <= 20; i++ {
+		files[fmt.Sprintf("post-%02d.md", i)] = fmt.Sprintf("---\ntitle: Post %d\ndate: 2024-01-%02d\n---\nShared words in every post.", i, i)
+	}
+
+	first, err := json.Marshal(newTestBlog(t, files).NewSearchIndex())
+	if err != nil {
+		t.Fatalf("Failed to marshal index: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		again, _ := json.Marshal(newTestBlog(t, files).NewSearchIndex())
+		if string(again) != string(first) {
+			t.Fatalf("Expected identical search index JSON for the same posts:\n%s\n%s", first, again)
+		}
+	}
+
+	index := newTestBlog(t, files).NewSearchIndex()
+	if ids := index.InvertedIndex["shared"]; len(ids) != 20 || !sort.StringsAreSorted(ids) {
+		t.Errorf("Expected a sorted posting list of all 20 posts, got %v", ids)
+	}
+}
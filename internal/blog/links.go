@@ -0,0 +1,140 @@
+package blog
+
+import (
+	"bytes"
+	"html"
+	"html/template"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// wikiLinks parses the shorthand [[slug]] into a link to that post. The
+// link text is the slug until resolveInternalLinks replaces it with the
+// post's title, which is only known once every post is loaded.
+type wikiLinks struct{}
+
+func (wikiLinks) Trigger() []byte {
+	return []byte{'['}
+}
+
+func (wikiLinks) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, _ := block.PeekLine()
+	if !bytes.HasPrefix(line, []byte("[[")) {
+		return nil
+	}
+	end := bytes.Index(line, []byte("]]"))
+	if end < 0 {
+		return nil
+	}
+	slug := string(line[2:end])
+	if !validSlugPattern.MatchString(slug) {
+		return nil
+	}
+	block.Advance(end + 2)
+
+	link := ast.NewLink()
+	link.Destination = []byte("/post/" + slug + "/")
+	link.SetAttributeString("class", []byte("wikilink"))
+	link.AppendChild(link, ast.NewString([]byte(slug)))
+	return link
+}
+
+// externalLinks makes links that leave the site open in a new tab, with
+// rel="noopener noreferrer" so the opened page gets neither a handle on
+// this one nor its URL. Relative links, fragments and mailto: links are
+// left alone.
+type externalLinks struct {
+	host string // of Config.BaseURL; http(s) links to other hosts are external
+}
+
+func newExternalLinks(baseURL string) externalLinks {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return externalLinks{}
+	}
+	return externalLinks{host: u.Host}
+}
+
+func (e externalLinks) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		var dest []byte
+		switch link := n.(type) {
+		case *ast.Link:
+			dest = link.Destination
+		case *ast.AutoLink:
+			if link.AutoLinkType == ast.AutoLinkURL {
+				dest = link.URL(reader.Source())
+			}
+		}
+		if entering && dest != nil && e.isExternal(string(dest)) {
+			n.SetAttributeString("target", []byte("_blank"))
+			n.SetAttributeString("rel", []byte("noopener noreferrer"))
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+func (e externalLinks) isExternal(dest string) bool {
+	u, err := url.Parse(dest)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+	return !strings.EqualFold(u.Host, e.host)
+}
+
+// postLinkPattern matches links to other posts in rendered HTML.
+var postLinkPattern = regexp.MustCompile(`href="/post/([^/"?#]+)`)
+
+// wikiLinkPattern matches the rendered form of a [[slug]] link.
+var wikiLinkPattern = regexp.MustCompile(`<a href="/post/([a-z0-9-]+)/" class="wikilink">[a-z0-9-]+</a>`)
+
+// resolveInternalLinks titles the wiki-links in posts and warns about links
+// to posts that do not exist, which would 404 once published.
+func (b *Blog) resolveInternalLinks(posts []*Post) {
+	for _, post := range posts {
+		content := wikiLinkPattern.ReplaceAllStringFunc(string(post.HTMLContent), func(link string) string {
+			slug := wikiLinkPattern.FindStringSubmatch(link)[1]
+			target, ok := b.posts[slug]
+			if !ok {
+				return link
+			}
+			return `<a href="/post/` + slug + `/" class="wikilink">` + html.EscapeString(target.Title) + `</a>`
+		})
+		post.HTMLContent = template.HTML(content)
+
+		for _, target := range brokenPostLinks(content, b.linkTargetExists) {
+			slog.Warn("Broken internal link", "slug", post.ID, "target", "/post/"+target+"/")
+		}
+	}
+}
+
+// linkTargetExists reports whether /post/{slug}/ leads somewhere once
+// published: a post or a redirect.
+func (b *Blog) linkTargetExists(slug string) bool {
+	if _, ok := b.posts[slug]; ok {
+		return true
+	}
+	_, ok := b.redirects[slug]
+	return ok
+}
+
+// brokenPostLinks returns the slugs of the /post/ links in content for
+// which exists reports false.
+func brokenPostLinks(content string, exists func(slug string) bool) []string {
+	var broken []string
+	for _, match := range postLinkPattern.FindAllStringSubmatch(content, -1) {
+		if !exists(match[1]) {
+			broken = append(broken, match[1])
+		}
+	}
+	return broken
+}
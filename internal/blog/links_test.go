@@ -0,0 +1,79 @@
+package blog
+
+import (
+	"bytes"
+	"embed"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestInternalLinks(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	blog := newTestBlog(t, map[string]string{
+		"target.md":   "---\ntitle: Target & Friends\ndate: 2024-01-01\n---\nHere.",
+		"valid.md":    "---\ntitle: Valid\ndate: 2024-01-02\n---\nSee [the target](/post/target/).",
+		"dangling.md": "---\ntitle: Dangling\ndate: 2024-01-03\n---\nSee [nowhere](/post/nowhere/).",
+		"wiki.md":     "---\ntitle: Wiki\ndate: 2024-01-04\n---\nRead [[target]] next, not `[[target]]`.",
+	})
+
+	if strings.Contains(logs.String(), "slug=valid") {
+		t.Errorf("Expected no warning for a valid internal link, got %q", logs.String())
+	}
+	if !strings.Contains(logs.String(), "Broken internal link") || !strings.Contains(logs.String(), "/post/nowhere/") {
+		t.Errorf("Expected a warning about the dangling link, got %q", logs.String())
+	}
+
+	wiki := string(blog.posts["wiki"].HTMLContent)
+	if !strings.Contains(wiki, `<a href="/post/target/" class="wikilink">Target &amp; Friends</a>`) {
+		t.Errorf("Expected [[target]] to expand to a titled link, got %q", wiki)
+	}
+	if !strings.Contains(wiki, "<code>[[target]]</code>") {
+		t.Errorf("Expected [[target]] inside code to stay literal, got %q", wiki)
+	}
+}
+
+func TestWikiLinkToMissingPost(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	blog := newTestBlog(t, map[string]string{
+		"wiki.md": "---\ntitle: Wiki\ndate: 2024-01-04\n---\nRead [[missing]].",
+	})
+
+	if html := string(blog.posts["wiki"].HTMLContent); !strings.Contains(html, `<a href="/post/missing/" class="wikilink">missing</a>`) {
+		t.Errorf("Expected an unresolved wiki-link to keep its slug, got %q", html)
+	}
+	if !strings.Contains(logs.String(), "/post/missing/") {
+		t.Errorf("Expected a warning about the missing wiki-link target, got %q", logs.String())
+	}
+}
+
+func TestExternalLinks(t *testing.T) {
+	blog, _ := NewBlogWithConfig(Config{BaseURL: "https://blog.example.com"}, embed.FS{}, embed.FS{}, embed.FS{})
+	post, err := blog.parsePost("links.md", "---\ntitle: Links\ndate: 2024-01-27\n---\n"+
+		"[Go](https://go.dev/doc/), [home](https://blog.example.com/about/), [next](/post/next/), "+
+		"[top](#intro), [mail](mailto:me@example.com) and https://pkg.go.dev")
+	if err != nil {
+		t.Fatalf("Failed to parse post: %v", err)
+	}
+	html := string(post.HTMLContent)
+
+	for _, want := range []string{
+		`<a href="https://go.dev/doc/" target="_blank" rel="noopener noreferrer">Go</a>`,
+		`<a href="https://pkg.go.dev" target="_blank" rel="noopener noreferrer">https://pkg.go.dev</a>`,
+		`<a href="https://blog.example.com/about/">home</a>`,
+		`<a href="/post/next/">next</a>`,
+		`<a href="#intro">top</a>`,
+		`<a href="mailto:me@example.com">mail</a>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Expected %s in %s", want, html)
+		}
+	}
+}
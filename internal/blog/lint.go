@@ -0,0 +1,103 @@
+package blog
+
+import (
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LintIssue is a problem Lint found in one markdown file.
+type LintIssue struct {
+	Path    string
+	Message string
+}
+
+func (i LintIssue) String() string {
+	return i.Path + ": " + i.Message
+}
+
+// Lint checks every markdown file in the content directory and reports all
+// problems at once, sorted by path, including those LoadPosts skips posts
+// for or quietly falls back to defaults on. It does not change the loaded posts.
+func (b *Blog) Lint() []LintIssue {
+	var issues []LintIssue
+	report := func(path, format string, args ...any) {
+		issues = append(issues, LintIssue{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	posts := make(map[string]*Post)  // by post ID
+	paths := make(map[string]string) // post ID -> file
+	sources := make(map[string]string)
+	err := fs.WalkDir(b.blogFS, b.Config.ContentDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || postExt(entry.Name()) == "" {
+			return nil
+		}
+
+		content, err := fs.ReadFile(b.blogFS, path)
+		if err != nil {
+			report(path, "cannot be read: %v", err)
+			return nil
+		}
+		fm, _, err := splitFrontmatter(string(content))
+		if err != nil {
+			report(path, "%v", err)
+			return nil
+		}
+		post, err := b.parsePost(strings.TrimPrefix(path, b.Config.ContentDir+"/"), string(content))
+		if err != nil {
+			report(path, "%v", err)
+			return nil
+		}
+
+		if strings.TrimSpace(fm.Title) == "" {
+			report(path, "missing title")
+		}
+		if fm.Date != "" {
+			if _, err := parseFrontmatterDate(string(fm.Date), time.UTC); err != nil {
+				report(path, "unparseable date %q, want YYYY-MM-DD or RFC 3339", fm.Date)
+			}
+		}
+		if post.Content == "" {
+			report(path, "empty content")
+		}
+
+		key := strings.ToLower(post.ID)
+		if existing, ok := sources[key]; ok {
+			report(path, "duplicate slug %q, also used by %s", post.ID, existing)
+			return nil
+		}
+		sources[key] = path
+		posts[post.ID] = post
+		paths[post.ID] = path
+		return nil
+	})
+	if err != nil {
+		report(b.Config.ContentDir, "cannot be read: %v", err)
+	}
+
+	for id, post := range posts {
+		if post.Draft {
+			continue
+		}
+		published := func(slug string) bool {
+			if linked, ok := posts[slug]; ok {
+				return !linked.Draft && !b.scheduled(linked)
+			}
+			_, ok := b.redirects[slug]
+			return ok
+		}
+		for _, target := range brokenPostLinks(string(post.HTMLContent), published) {
+			report(paths[id], "broken link to /post/%s/", target)
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Path < issues[j].Path
+	})
+	return issues
+}
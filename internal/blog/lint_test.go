@@ -0,0 +1,52 @@
+package blog
+
+import (
+	"embed"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLint(t *testing.T) {
+	blog, _ := NewBlog(embed.FS{}, embed.FS{}, embed.FS{})
+	blog.blogFS = fstest.MapFS{
+		"blog/good.md":         {Data: []byte("---\ntitle: Good\ndate: 2024-01-01\n---\nSee [the other post](/post/other/).")},
+		"blog/other.md":        {Data: []byte("---\ntitle: Other\ndate: 2024-01-02\n---\nBack to [good](/post/good/) and [moved](/post/moved/).")},
+		"blog/untitled.md":     {Data: []byte("---\ndate: 2024-01-03\n---\nNo title.")},
+		"blog/bad-date.md":     {Data: []byte("---\ntitle: Bad Date\ndate: 03/01/2024\n---\nBody.")},
+		"blog/empty.md":        {Data: []byte("---\ntitle: Empty\ndate: 2024-01-04\n---\n")},
+		"blog/later-dup.md":    {Data: []byte("---\ntitle: Dup\ndate: 2024-01-05\nslug: good\n---\nBody.")},
+		"blog/links.md":        {Data: []byte("---\ntitle: Links\ndate: 2024-01-06\n---\n[gone](/post/missing/) and [draft](/post/wip/).")},
+		"blog/wip.md":          {Data: []byte("---\ntitle: WIP\ndate: 2024-01-07\ndraft: true\n---\nLater.")},
+		"blog/nofront.md":      {Data: []byte("Just text.")},
+		"blog/unterminated.md": {Data: []byte("---\ntitle: Open\n")},
+	}
+	blog.redirects = map[string]string{"moved": "/post/good/"}
+
+	var got []string
+	for _, issue := range blog.Lint() {
+		got = append(got, issue.String())
+	}
+	want := []string{
+		"blog/bad-date.md: unparseable date \"03/01/2024\", want YYYY-MM-DD or RFC 3339",
+		"blog/empty.md: empty content",
+		"blog/later-dup.md: duplicate slug \"good\", also used by blog/good.md",
+		"blog/links.md: broken link to /post/missing/",
+		"blog/links.md: broken link to /post/wip/",
+		"blog/nofront.md: " + errNoFrontmatter.Error(),
+		"blog/unterminated.md: unterminated frontmatter: missing closing --- line",
+		"blog/untitled.md: missing title",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected lint issues:\ngot  %q\nwant %q", got, want)
+	}
+}
+
+func TestLintClean(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHi.",
+	})
+	if issues := blog.Lint(); len(issues) != 0 {
+		t.Errorf("Expected no issues, got %v", issues)
+	}
+}
@@ -0,0 +1,30 @@
+package blog
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// NewLogger returns a logger writing to w. format is "json" or "text" (the
+// default) and level is a slog level name such as "debug", "info", "warn"
+// or "error"; an empty or unknown level means info.
+func NewLogger(w io.Writer, format, level string) *slog.Logger {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		lvl = slog.LevelInfo
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	if strings.EqualFold(format, "json") {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// NewLoggerFromEnv returns a stderr logger configured by the LOG_FORMAT and
+// LOG_LEVEL environment variables.
+func NewLoggerFromEnv() *slog.Logger {
+	return NewLogger(os.Stderr, os.Getenv("LOG_FORMAT"), os.Getenv("LOG_LEVEL"))
+}
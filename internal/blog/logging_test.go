@@ -0,0 +1,37 @@
+package blog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNewLoggerLevel(t *testing.T) {
+	var out bytes.Buffer
+	logger := NewLogger(&out, "text", "error")
+
+	logger.Info("routine message")
+	logger.Warn("warning message")
+	if out.Len() != 0 {
+		t.Errorf("Expected messages below error to be suppressed, got %q", out.String())
+	}
+
+	logger.Error("failure message")
+	if !strings.Contains(out.String(), "failure message") {
+		t.Errorf("Expected error message to be logged, got %q", out.String())
+	}
+}
+
+func TestNewLoggerJSON(t *testing.T) {
+	var out bytes.Buffer
+	NewLogger(&out, "json", "").Info("hello", "path", "blog/a.md")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected a JSON log line, got %q: %v", out.String(), err)
+	}
+	if entry["level"] != "INFO" || entry["msg"] != "hello" || entry["path"] != "blog/a.md" {
+		t.Errorf("Unexpected JSON log entry %v", entry)
+	}
+}
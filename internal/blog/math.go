@@ -0,0 +1,54 @@
+package blog
+
+import (
+	"bytes"
+	"unicode"
+
+	mathjax "github.com/litao91/goldmark-mathjax"
+	"github.com/yuin/goldmark/ast"
+)
+
+// hasMath reports whether a parsed document contains math, so post pages
+// only load KaTeX when they need it. The math parser takes any pair of
+// dollar signs, so inline math that reads like prices ("$5 or $10") is
+// ignored: the content must not start or end with a space, and the closing
+// dollar must not be followed by a digit.
+func hasMath(doc ast.Node, source []byte) bool {
+	found := false
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n.Kind() {
+		case mathjax.KindMathBlock:
+			found = true
+		case mathjax.KindInlineMath:
+			found = isInlineMath(n, source)
+		}
+		if found {
+			return ast.WalkStop, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return found
+}
+
+func isInlineMath(n ast.Node, source []byte) bool {
+	var content []byte
+	end := -1
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		if text, ok := c.(*ast.Text); ok {
+			content = append(content, text.Segment.Value(source)...)
+			end = text.Segment.Stop
+		}
+	}
+	if len(bytes.TrimSpace(content)) == 0 || len(bytes.TrimSpace(content)) != len(content) {
+		return false
+	}
+
+	// Skip the closing dollars and look at what follows them.
+	for end < len(source) && source[end] == '$' {
+		end++
+	}
+	return end >= len(source) || !unicode.IsDigit(rune(source[end]))
+}
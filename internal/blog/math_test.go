@@ -0,0 +1,29 @@
+package blog
+
+import "testing"
+
+func TestParsePostHasMath(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"inline.md": "---\ntitle: Inline\ndate: 2024-01-01\n---\nThe area is $x^2$ square units.",
+		"block.md":  "---\ntitle: Block\ndate: 2024-01-02\n---\n$$\n\\int_0^1 x\\,dx\n$$",
+		"price.md":  "---\ntitle: Price\ndate: 2024-01-03\n---\nIt costs $5.",
+		"prices.md": "---\ntitle: Prices\ndate: 2024-01-04\n---\nPlans cost $5 or $10 a month.",
+		"range.md":  "---\ntitle: Range\ndate: 2024-01-05\n---\nBetween $5-$10 each.",
+		"code.md":   "---\ntitle: Code\ndate: 2024-01-06\n---\nRun `echo $HOME$PATH` first.",
+		"nomath.md": "---\ntitle: No Math\ndate: 2024-01-07\n---\nJust prose.",
+	})
+
+	for id, want := range map[string]bool{
+		"inline": true,
+		"block":  true,
+		"price":  false,
+		"prices": false,
+		"range":  false,
+		"code":   false,
+		"nomath": false,
+	} {
+		if got := blog.posts[id].HasMath; got != want {
+			t.Errorf("%s: expected HasMath %v, got %v", id, want, got)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package blog
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// statusRecorder captures the status code and body size written through a
+// ResponseWriter.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(p)
+	r.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// logRequests logs the method, path, status, response size and duration of
+// every request handled by next.
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		slog.Info("request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", status,
+			"bytes", rec.bytes,
+			"duration", time.Since(start))
+	})
+}
+
+// headWriter drops the body of a response to a HEAD request, keeping its
+// status and headers.
+type headWriter struct {
+	http.ResponseWriter
+}
+
+func (w headWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w headWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// withoutHeadBodies answers HEAD requests with the headers next writes for
+// GET, so link checkers don't download whole pages.
+func withoutHeadBodies(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w = headWriter{w}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// healthPath answers health checks. It is exempt from the canonical host
+// redirect because load balancers probe it by IP or internal host name.
+const healthPath = "/healthz"
+
+// canonicalHost permanently redirects requests for any host other than host
+// to the same path and query on host. Ports are ignored when comparing, so
+// the preview server on localhost:8080 counts as localhost. With
+// trustProxy, X-Forwarded-Proto picks the scheme of the redirect; clients
+// can set it themselves otherwise. An empty host disables the redirect.
+func canonicalHost(host string, trustProxy bool, next http.Handler) http.Handler {
+	if host == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.EqualFold(hostname(r.Host), hostname(host)) || r.URL.Path == healthPath {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		scheme := "http"
+		if r.TLS != nil || (trustProxy && r.Header.Get("X-Forwarded-Proto") == "https") {
+			scheme = "https"
+		}
+		http.Redirect(w, r, scheme+"://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
+
+// hostname returns host without its port, if it has one.
+func hostname(host string) string {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		return name
+	}
+	return host
+}
+
+type nonceKey struct{}
+
+// securityHeaders sets X-Content-Type-Options, Referrer-Policy and, when
+// policy is not empty, a Content-Security-Policy in which {nonce} is
+// replaced by a fresh nonce for each request. Handlers pass the nonce to
+// templates via cspNonce so their inline scripts are allowed to run.
+func securityHeaders(policy string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
+		if policy != "" {
+			nonce := newNonce()
+			w.Header().Set("Content-Security-Policy", strings.ReplaceAll(policy, "{nonce}", nonce))
+			r = r.WithContext(context.WithValue(r.Context(), nonceKey{}, nonce))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// newNonce returns 128 random bits, encoded so templates need not escape them.
+func newNonce() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// cspNonce returns the nonce securityHeaders generated for r, or "" when
+// no policy is in effect.
+func cspNonce(r *http.Request) string {
+	nonce, _ := r.Context().Value(nonceKey{}).(string)
+	return nonce
+}
@@ -0,0 +1,149 @@
+package blog
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLogRequests(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHello.",
+	})
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	router := blog.Router()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/post/missing/", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/static/style.css", nil))
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected one log line per request, got %q", logs.String())
+	}
+	if !strings.Contains(lines[0], `method=GET path=/post/missing/ status=404`) {
+		t.Errorf("Expected a 404 log line for the missing post, got %q", lines[0])
+	}
+	if !strings.Contains(lines[1], `path=/static/style.css status=200`) || strings.Contains(lines[1], "bytes=0 ") {
+		t.Errorf("Expected a 200 log line with the body size for the static file, got %q", lines[1])
+	}
+	if !strings.Contains(lines[1], "duration=") {
+		t.Errorf("Expected a duration in the log line, got %q", lines[1])
+	}
+}
+
+func TestCanonicalHostRedirect(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHi.",
+	})
+	blog.Config.CanonicalHost = "www.example.com"
+	router := blog.Router()
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/search/?q=go", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMovedPermanently {
+		t.Fatalf("Expected status 301 for a mismatched host, got %d", rec.Code)
+	}
+	if loc := rec.Header().Get("Location"); loc != "http://www.example.com/search/?q=go" {
+		t.Errorf("Expected X-Forwarded-Proto to be ignored without trust_proxy, got %q", loc)
+	}
+
+	blog.Config.TrustProxy = true
+	rec = httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, req)
+	if loc := rec.Header().Get("Location"); loc != "https://www.example.com/search/?q=go" {
+		t.Errorf("Expected redirect to the canonical host over https behind a proxy, got %q", loc)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://example.com/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected health check to skip the redirect, got %d", rec.Code)
+	}
+}
+
+func TestCanonicalHostPassThrough(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHi.",
+	})
+
+	blog.Config.CanonicalHost = "www.example.com"
+	rec := httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://WWW.example.com/post/hello/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected the canonical host to pass through, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://www.example.com:8080/post/hello/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected the canonical host on another port to pass through, got %d", rec.Code)
+	}
+
+	blog.Config.CanonicalHost = ""
+	rec = httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://anything.test/post/hello/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected no redirect without a canonical host, got %d", rec.Code)
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHi.",
+	})
+	router := blog.Router()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/post/hello/", nil))
+	if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("Expected X-Content-Type-Options nosniff, got %q", got)
+	}
+	if got := rec.Header().Get("Referrer-Policy"); got != "strict-origin-when-cross-origin" {
+		t.Errorf("Expected Referrer-Policy strict-origin-when-cross-origin, got %q", got)
+	}
+
+	csp := rec.Header().Get("Content-Security-Policy")
+	match := regexp.MustCompile(`'nonce-([^']+)'`).FindStringSubmatch(csp)
+	if match == nil {
+		t.Fatalf("Expected a nonce in the Content-Security-Policy, got %q", csp)
+	}
+	if !strings.Contains(csp, "https://cdn.jsdelivr.net") {
+		t.Errorf("Expected the default policy to allow the KaTeX CDN, got %q", csp)
+	}
+	body := rec.Body.String()
+	inline := strings.Count(body, "<script") - strings.Count(body, "<script src") -
+		strings.Count(body, "<script async") - strings.Count(body, "<script defer") -
+		strings.Count(body, `<script type="application/ld+json"`)
+	if inline == 0 || strings.Count(body, `<script nonce="`+match[1]+`">`) != inline {
+		t.Errorf("Expected all %d inline scripts to carry nonce %q", inline, match[1])
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/post/hello/", nil))
+	if rec.Header().Get("Content-Security-Policy") == csp {
+		t.Errorf("Expected a fresh nonce for each request")
+	}
+}
+
+func TestSecurityHeadersCustomPolicy(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHi.",
+	})
+	blog.Config.CSP = "default-src 'self'"
+
+	rec := httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := rec.Header().Get("Content-Security-Policy"); got != "default-src 'self'" {
+		t.Errorf("Expected the configured policy, got %q", got)
+	}
+}
@@ -0,0 +1,88 @@
+package blog
+
+import (
+	"net/http"
+	"sort"
+	"unicode/utf8"
+)
+
+// maxNotFoundSuggestions is how many similar posts the 404 page offers.
+const maxNotFoundSuggestions = 3
+
+// notFoundData builds the 404 page for a request for slug, suggesting the
+// posts whose slugs or titles are closest to it.
+func (b *Blog) notFoundData(slug string) map[string]interface{} {
+	return map[string]interface{}{
+		"Title":       "Page Not Found",
+		"Slug":        slug,
+		"Suggestions": b.similarPosts(slug, maxNotFoundSuggestions),
+		"Config":      b.Config,
+		"Theme":       b.Config.DefaultTheme,
+	}
+}
+
+// notFound answers with the 404 page, suggesting posts similar to slug.
+func (b *Blog) notFound(w http.ResponseWriter, r *http.Request, slug string) {
+	data := b.notFoundData(slug)
+	data["Theme"] = b.themeFor(r)
+	data["Nonce"] = cspNonce(r)
+	b.renderStatus(w, http.StatusNotFound, "404.html", data)
+}
+
+// similarPosts returns up to n published posts whose slug, or slugified
+// title, is within a few edits of slug, closest first. Ties keep listing
+// order. The allowed distance grows with the slug's length, so short
+// typos and longer garbled slugs both find their post.
+func (b *Blog) similarPosts(slug string, n int) []*Post {
+	if slug == "" {
+		return nil
+	}
+	maxDistance := utf8.RuneCountInString(slug) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+
+	type candidate struct {
+		post     *Post
+		distance int
+	}
+	var candidates []candidate
+	for _, post := range b.postList {
+		distance := min(levenshtein(slug, post.Slug), levenshtein(slug, slugify(post.Title)))
+		if distance <= maxDistance {
+			candidates = append(candidates, candidate{post, distance})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+
+	var posts []*Post
+	for i := 0; i < len(candidates) && i < n; i++ {
+		posts = append(posts, candidates[i].post)
+	}
+	return posts
+}
+
+// levenshtein returns the number of single-rune insertions, deletions and
+// substitutions that turn a into b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,57 @@
+package blog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"go", "", 2},
+		{"hello-world", "hello-world", 0},
+		{"helo-world", "hello-world", 1},
+		{"kitten", "sitting", 3},
+		{"café", "cafe", 1},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestNotFoundSuggestions(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello-world.md":  "---\ntitle: Hello World\ndate: 2024-01-27\n---\nHi.",
+		"intro.md":        "---\ntitle: Getting Started with Go\ndate: 2024-01-28\n---\nGo.",
+		"unrelated.md":    "---\ntitle: Something Else Entirely\ndate: 2024-01-29\n---\nNo.",
+		"secret-draft.md": "---\ntitle: Hello Draft\ndate: 2024-01-30\ndraft: true\n---\nShh.",
+	})
+
+	rec := httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/post/helo-wrld/", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("Expected status 404, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `href="/post/hello-world/"`) {
+		t.Errorf("Expected a suggestion for hello-world, got %q", body)
+	}
+	if strings.Contains(body, "/post/unrelated/") || strings.Contains(body, "secret-draft") {
+		t.Errorf("Expected no suggestions for unrelated posts or drafts")
+	}
+
+	// A typo in the title works as well as one in the slug.
+	if posts := blog.similarPosts("getting-startd-with-go", 3); len(posts) != 1 || posts[0].Slug != "intro" {
+		t.Errorf("Expected the post titled Getting Started with Go, got %v", posts)
+	}
+	if posts := blog.similarPosts("zzz", 3); len(posts) != 0 {
+		t.Errorf("Expected no suggestions for a slug like no post, got %v", posts)
+	}
+}
@@ -0,0 +1,209 @@
+package blog
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Generated Open Graph images use the size social networks recommend.
+const (
+	ogImageWidth  = 1200
+	ogImageHeight = 630
+	ogImageMargin = 80
+	ogTitleSize   = 64 // title font size, in pixels
+	ogNameSize    = 32 // blog name font size, in pixels
+	ogMaxLines    = 4
+)
+
+// ogBackgrounds are dark backgrounds for generated images. Each post gets
+// one picked by its slug, so a post keeps its color between builds.
+var ogBackgrounds = []color.RGBA{
+	{0x1e, 0x29, 0x3b, 0xff}, // slate
+	{0x1e, 0x3a, 0x8a, 0xff}, // blue
+	{0x13, 0x4e, 0x4a, 0xff}, // teal
+	{0x3b, 0x07, 0x64, 0xff}, // purple
+	{0x7c, 0x2d, 0x12, 0xff}, // rust
+	{0x27, 0x27, 0x2a, 0xff}, // zinc
+}
+
+var (
+	ogTitleColor = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	ogNameColor  = color.RGBA{0xa1, 0xa1, 0xaa, 0xff}
+)
+
+// ogImagePath is the site path of the generated preview image for slug.
+func ogImagePath(slug string) string {
+	return "/static/og/" + slug + ".png"
+}
+
+// ogImageURL returns the absolute URL of the post's preview image, falling
+// back to the site-wide image for posts without one.
+func (b *Blog) ogImageURL(post *Post) string {
+	switch {
+	case post.Image == "":
+		return b.Config.BaseURL + "/static/og-image.png"
+	case strings.HasPrefix(post.Image, "http://"), strings.HasPrefix(post.Image, "https://"):
+		return post.Image
+	default:
+		return b.Config.BaseURL + "/" + strings.TrimPrefix(post.Image, "/")
+	}
+}
+
+// ogFonts parses the embedded Go fonts once: bold for titles, regular for
+// the blog name.
+var ogFonts = sync.OnceValues(func() ([2]*opentype.Font, error) {
+	var fonts [2]*opentype.Font
+	for i, ttf := range [][]byte{gobold.TTF, goregular.TTF} {
+		f, err := opentype.Parse(ttf)
+		if err != nil {
+			return fonts, fmt.Errorf("failed to parse font: %w", err)
+		}
+		fonts[i] = f
+	}
+	return fonts, nil
+})
+
+// renderOGImage draws a PNG preview image with the post title wrapped over
+// a colored background and the blog name along the bottom. Text is set in
+// the Go fonts, which cover Latin, Greek and Cyrillic; other letters are
+// drawn as boxes.
+func renderOGImage(title, blogName, slug string) ([]byte, error) {
+	fonts, err := ogFonts()
+	if err != nil {
+		return nil, err
+	}
+	// Faces keep per-face caches, so each image gets its own.
+	titleFace, err := opentype.NewFace(fonts[0], &opentype.FaceOptions{Size: ogTitleSize, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, err
+	}
+	defer titleFace.Close()
+	nameFace, err := opentype.NewFace(fonts[1], &opentype.FaceOptions{Size: ogNameSize, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, err
+	}
+	defer nameFace.Close()
+
+	img := image.NewRGBA(image.Rect(0, 0, ogImageWidth, ogImageHeight))
+	h := fnv.New32a()
+	h.Write([]byte(slug))
+	background := ogBackgrounds[h.Sum32()%uint32(len(ogBackgrounds))]
+	draw.Draw(img, img.Bounds(), &image.Uniform{background}, image.Point{}, draw.Src)
+
+	maxWidth := ogImageWidth - 2*ogImageMargin
+	lines := wrapText(title, func(line string) bool {
+		return font.MeasureString(titleFace, line).Ceil() <= maxWidth
+	}, ogMaxLines)
+
+	// Center the title in the space above the blog name. Lines are spaced
+	// by the font's line height; y tracks the top of each line.
+	titleMetrics, nameMetrics := titleFace.Metrics(), nameFace.Metrics()
+	lineHeight := titleMetrics.Height.Ceil() * 6 / 5
+	nameHeight := nameMetrics.Height.Ceil()
+	titleHeight := (len(lines)-1)*lineHeight + titleMetrics.Height.Ceil()
+	y := ogImageMargin + (ogImageHeight-2*ogImageMargin-nameHeight-titleHeight)/2
+	for _, line := range lines {
+		drawText(img, titleFace, ogImageMargin, y+titleMetrics.Ascent.Ceil(), line, ogTitleColor)
+		y += lineHeight
+	}
+	drawText(img, nameFace, ogImageMargin, ogImageHeight-ogImageMargin-nameMetrics.Descent.Ceil(), blogName, ogNameColor)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// wrapText breaks s into at most maxLines lines for which fits reports
+// true, splitting words that are longer than a line and ending a
+// truncated text with "..." after its last whole word.
+func wrapText(s string, fits func(line string) bool, maxLines int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		for !fits(word) {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			// Keep at least one letter per line, so a word always shrinks.
+			runes := []rune(word)
+			n := 1
+			for n < len(runes) && fits(string(runes[:n+1])) {
+				n++
+			}
+			lines = append(lines, string(runes[:n]))
+			word = string(runes[n:])
+		}
+		switch {
+		case word == "":
+		case line == "":
+			line = word
+		case fits(line + " " + word):
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+
+	if len(lines) > maxLines {
+		lines = lines[:maxLines]
+		last := lines[maxLines-1]
+		for last != "" && !fits(last+"...") {
+			if i := strings.LastIndex(last, " "); i > 0 {
+				last = last[:i]
+			} else {
+				runes := []rune(last)
+				last = string(runes[:len(runes)-1])
+			}
+		}
+		lines[maxLines-1] = last + "..."
+	}
+	return lines
+}
+
+// drawText draws s in face with its baseline starting at x, y.
+func drawText(img draw.Image, face font.Face, x, y int, s string, c color.Color) {
+	d := font.Drawer{Dst: img, Src: image.NewUniform(c), Face: face, Dot: fixed.P(x, y)}
+	d.DrawString(s)
+}
+
+// handleOGImage renders the generated preview image of a post on demand,
+// standing in for the files the static export writes under static/og/.
+func (b *Blog) handleOGImage(w http.ResponseWriter, r *http.Request) {
+	slug := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/static/og/"), ".png")
+	post, ok := b.posts[slug]
+	if !ok || !b.Config.OGImages || post.Image != ogImagePath(slug) {
+		http.NotFound(w, r)
+		return
+	}
+
+	data, err := renderOGImage(post.Title, b.Config.BlogName, slug)
+	if err != nil {
+		slog.Error("Error generating preview image", "slug", slug, "err", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(data)
+}
@@ -0,0 +1,117 @@
+package blog
+
+import (
+	"bytes"
+	"embed"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"unicode/utf8"
+)
+
+func TestRenderOGImage(t *testing.T) {
+	data, err := renderOGImage("Hello, World: a post about Go", "My Blog", "hello")
+	if err != nil {
+		t.Fatalf("Failed to render image: %v", err)
+	}
+	if len(data) == 0 {
+		t.Fatal("Expected a non-empty PNG")
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Failed to decode PNG: %v", err)
+	}
+	if size := img.Bounds().Size(); size.X != 1200 || size.Y != 630 {
+		t.Errorf("Expected a 1200x630 image, got %dx%d", size.X, size.Y)
+	}
+
+	// Letters outside ASCII are drawn, not left blank.
+	data, err = renderOGImage("Çé", "", "accents")
+	if err != nil {
+		t.Fatalf("Failed to render image: %v", err)
+	}
+	img, _ = png.Decode(bytes.NewReader(data))
+	drawn := 0
+	for y := 0; y < 630; y++ {
+		for x := 0; x < 1200; x++ {
+			if r, g, b, _ := img.At(x, y).RGBA(); r == 0xffff && g == 0xffff && b == 0xffff {
+				drawn++
+			}
+		}
+	}
+	if drawn == 0 {
+		t.Error("Expected the accented title to be drawn")
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	tests := []struct {
+		text  string
+		lines []string
+	}{
+		{"Hello", []string{"Hello"}},
+		{"Hello World", []string{"Hello", "World"}},
+		{"one two three four", []string{"one two", "three four"}},
+		{"abcdefghijklmn", []string{"abcdefghij", "klmn"}},
+		{"aa bb cc dd ee ff gg hh ii jj", []string{"aa bb cc", "dd ee..."}},
+	}
+	fits := func(line string) bool { return utf8.RuneCountInString(line) <= 10 }
+	for _, tt := range tests {
+		if got := wrapText(tt.text, fits, 2); !reflect.DeepEqual(got, tt.lines) {
+			t.Errorf("wrapText(%q) = %q, want %q", tt.text, got, tt.lines)
+		}
+	}
+}
+
+func TestExportOGImages(t *testing.T) {
+	files := map[string]string{
+		"hello.md":  "---\ntitle: Hello\ndate: 2024-01-27\n---\nHi.",
+		"custom.md": "---\ntitle: Custom\ndate: 2024-01-28\nimage: https://example.com/custom.png\n---\nHi.",
+	}
+	if exported := newTestBlog(t, files).ExportFiles(); exported["static/og/hello.png"] != nil {
+		t.Fatal("Expected no generated images without og_images")
+	}
+
+	root := os.DirFS("../..")
+	blog, _ := NewBlog(root, root, embed.FS{})
+	blog.Config.OGImages = true
+	mapFS := fstest.MapFS{}
+	for name, content := range files {
+		mapFS["blog/"+name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	blog.blogFS = mapFS
+	if err := blog.LoadPosts(); err != nil {
+		t.Fatalf("Failed to load posts: %v", err)
+	}
+
+	exported := blog.ExportFiles()
+	if len(exported["static/og/hello.png"]) == 0 {
+		t.Fatal("Expected a generated image for the post without an image")
+	}
+	if exported["static/og/custom.png"] != nil {
+		t.Error("Expected no generated image for the post with its own image")
+	}
+	if page := string(exported["post/hello/index.html"]); !strings.Contains(page, blog.Config.BaseURL+"/static/og/hello.png") {
+		t.Errorf("Expected the post page to reference its generated image")
+	}
+	if page := string(exported["post/custom/index.html"]); !strings.Contains(page, "https://example.com/custom.png") {
+		t.Errorf("Expected the post page to reference its own image")
+	}
+
+	rec := httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/static/og/hello.png", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" {
+		t.Errorf("Expected the live server to render the image, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	rec = httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/static/og/custom.png", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a post with its own image, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,223 @@
+package blog
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AddMarkdown parses a markdown post with frontmatter, as stored in the blog
+// directory, and adds it with AddPost. filename determines the default slug.
+func (b *Blog) AddMarkdown(filename, content string) error {
+	post, err := b.parsePost(filename, content)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	return b.AddPost(post)
+}
+
+// LoadPostsFromDir adds the markdown posts under dir on disk to the posts
+// already loaded, so a running server can publish content without being
+// rebuilt. Files are read like the embedded blog directory: subdirectories
+// are folded into the slug. A post on disk replaces a loaded post with the
+// same slug. Like LoadPosts, it must not run concurrently with requests
+// being served.
+func (b *Blog) LoadPostsFromDir(dir string) error {
+	var errs []error
+	sources := make(map[string]string) // lowercased post ID -> file it came from
+	dirFS := os.DirFS(dir)
+	err := fs.WalkDir(dirFS, ".", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || postExt(entry.Name()) == "" {
+			return nil
+		}
+
+		content, err := readPost(dirFS, path, b.Config.MaxPostSize)
+		if errors.Is(err, errPostTooLarge) {
+			slog.Warn("Skipping post: size exceeds limit", "dir", dir, "path", path, "limit", b.Config.MaxPostSize)
+			return nil
+		}
+		if err != nil {
+			errs = append(errs, &LoadError{Path: filepath.Join(dir, path), Err: err})
+			return nil
+		}
+		post, err := b.parsePost(path, string(content))
+		if err != nil {
+			errs = append(errs, &LoadError{Path: filepath.Join(dir, path), Err: err})
+			return nil
+		}
+		if post.Date.IsZero() && b.Config.PathDates {
+			if date, ok := dateFromPath(path, b.location); ok {
+				post.Date = date
+			}
+		}
+
+		key := strings.ToLower(post.ID)
+		if existing, ok := sources[key]; ok {
+			slog.Warn("Slug collision, skipping post", "slug", post.ID, "path", path, "existing", existing)
+			errs = append(errs, fmt.Errorf("slug %q: %s collides with %s", post.ID, path, existing))
+			return nil
+		}
+		sources[key] = path
+
+		for _, loaded := range []map[string]*Post{b.posts, b.drafts} {
+			for id := range loaded {
+				if strings.EqualFold(id, post.ID) {
+					slog.Info("Post on disk replaces loaded post", "slug", id, "dir", dir, "path", path)
+					b.RemovePost(id)
+				}
+			}
+		}
+		if err := b.AddPost(post); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read posts from %s: %w", dir, err)
+	}
+	return errors.Join(errs...)
+}
+
+// scheduled reports whether post is dated in the future. Like drafts,
+// scheduled posts are only shown through preview links, until their date
+// passes and publishDue or a later load publishes them.
+// Config.PreviewFuture publishes them right away.
+func (b *Blog) scheduled(post *Post) bool {
+	return !b.Config.PreviewFuture && post.Date.After(b.now())
+}
+
+// noteScheduled keeps b.nextPublish at the earliest scheduled post held
+// back in b.drafts, so the live server can tell cheaply when one is due.
+func (b *Blog) noteScheduled(post *Post) {
+	if post.Draft || !b.scheduled(post) {
+		return
+	}
+	if b.nextPublish.IsZero() || post.Date.Before(b.nextPublish) {
+		b.nextPublish = post.Date
+	}
+}
+
+// due reports whether a scheduled post's date has passed since it was
+// held back.
+func (b *Blog) due() bool {
+	return !b.nextPublish.IsZero() && !b.nextPublish.After(b.now())
+}
+
+// publishDue publishes the scheduled posts whose date has passed, as a
+// reload would, and returns how many it published. Like AddPost, it must
+// not run concurrently with requests being served; the router calls it
+// between them.
+func (b *Blog) publishDue() int {
+	b.nextPublish = time.Time{}
+	var due []*Post
+	for _, post := range b.drafts {
+		if post.Draft {
+			continue
+		}
+		if b.scheduled(post) {
+			b.noteScheduled(post)
+			continue
+		}
+		due = append(due, post)
+	}
+
+	published := 0
+	for _, post := range due {
+		delete(b.drafts, post.ID)
+		if err := b.AddPost(post); err != nil {
+			slog.Warn("Error publishing scheduled post", "slug", post.ID, "err", err)
+			continue
+		}
+		slog.Info("Scheduled post published", "slug", post.ID)
+		published++
+	}
+	return published
+}
+
+// AddPost adds a post that did not come from the blog directory, such as one
+// loaded from a database. It keeps the listing order and indexes the
+// post for search without rebuilding the whole index. Drafts and scheduled
+// posts are kept aside for preview links only. Like LoadPosts, it
+// must not run concurrently with requests being served.
+func (b *Blog) AddPost(post *Post) error {
+	if post == nil || post.ID == "" {
+		return errors.New("post must have an ID")
+	}
+	if post.Slug == "" {
+		post.Slug = post.ID
+	}
+	if post.OGType == "" {
+		post.OGType = "article"
+	}
+	if post.Updated.IsZero() {
+		post.Updated = post.Date
+	}
+
+	for id := range b.posts {
+		if strings.EqualFold(id, post.ID) {
+			return fmt.Errorf("slug %q collides with existing post %q", post.ID, id)
+		}
+	}
+	for id := range b.drafts {
+		if strings.EqualFold(id, post.ID) {
+			return fmt.Errorf("slug %q collides with existing draft %q", post.ID, id)
+		}
+	}
+	if err := b.checkTags(post); err != nil {
+		if b.Config.StrictTags {
+			return fmt.Errorf("%s: %w", post.ID, err)
+		}
+		slog.Warn("Unapproved tags", "slug", post.ID, "err", err)
+	}
+
+	if post.Draft || b.scheduled(post) {
+		b.drafts[post.ID] = post
+		b.noteScheduled(post)
+		return nil
+	}
+
+	// Insert after every post listed no later, keeping postList sorted.
+	i := sort.Search(len(b.postList), func(i int) bool {
+		return postBefore(post, b.postList[i])
+	})
+	b.postList = append(b.postList, nil)
+	copy(b.postList[i+1:], b.postList[i:])
+	b.postList[i] = post
+	b.posts[post.ID] = post
+
+	b.resolveInternalLinks([]*Post{post})
+	b.indexPost(post)
+	b.invalidateSearchIndex()
+	return nil
+}
+
+// RemovePost removes the post with the given ID and drops it from the
+// search index. It reports whether the post existed.
+func (b *Blog) RemovePost(id string) bool {
+	if _, ok := b.drafts[id]; ok {
+		delete(b.drafts, id)
+		return true
+	}
+	if _, ok := b.posts[id]; !ok {
+		return false
+	}
+	delete(b.posts, id)
+	for i, post := range b.postList {
+		if post.ID == id {
+			b.postList = append(b.postList[:i], b.postList[i+1:]...)
+			break
+		}
+	}
+	b.unindexPost(id)
+	b.invalidateSearchIndex()
+	return true
+}
@@ -0,0 +1,144 @@
+package blog
+
+import (
+	"embed"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestAddPost(t *testing.T) {
+	blog, _ := NewBlog(embed.FS{}, embed.FS{}, embed.FS{})
+
+	if err := blog.AddMarkdown("middle.md", "---\ntitle: Middle\ndate: 2024-02-01\n---\nNotes about gophers."); err != nil {
+		t.Fatalf("Failed to add markdown: %v", err)
+	}
+	if err := blog.AddPost(&Post{ID: "newest", Title: "Newest", Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Content: "More gophers."}); err != nil {
+		t.Fatalf("Failed to add post: %v", err)
+	}
+	if err := blog.AddMarkdown("oldest.md", "---\ntitle: Oldest\ndate: 2024-01-01\n---\nNothing relevant."); err != nil {
+		t.Fatalf("Failed to add markdown: %v", err)
+	}
+
+	var order []string
+	for _, post := range blog.postList {
+		order = append(order, post.ID)
+	}
+	if want := []string{"newest", "middle", "oldest"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("Expected order %v, got %v", want, order)
+	}
+
+	results := blog.search("gophers")
+	if len(results) != 2 || results[0].ID != "newest" || results[1].ID != "middle" {
+		t.Errorf("Expected both gopher posts newest first, got %v", results)
+	}
+	if blog.posts["newest"].Slug != "newest" {
+		t.Errorf("Expected slug to default to the ID, got '%s'", blog.posts["newest"].Slug)
+	}
+
+	if err := blog.AddPost(&Post{ID: "Middle"}); err == nil {
+		t.Errorf("Expected a slug collision error")
+	}
+	if err := blog.AddMarkdown("broken.md", "no frontmatter"); err == nil {
+		t.Errorf("Expected a parse error")
+	}
+
+	if err := blog.AddPost(&Post{ID: "wip", Draft: true}); err != nil {
+		t.Fatalf("Failed to add draft: %v", err)
+	}
+	if err := blog.AddPost(&Post{ID: "WIP", Draft: true}); err == nil {
+		t.Errorf("Expected a slug collision error for a second draft")
+	}
+	if err := blog.AddPost(&Post{ID: "wip"}); err == nil {
+		t.Errorf("Expected a slug collision error with a draft")
+	}
+}
+
+func TestLoadPostsFromDir(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md":    "---\ntitle: Embedded Hello\ndate: 2024-01-27\n---\nFrom the binary.",
+		"embedded.md": "---\ntitle: Embedded Only\ndate: 2024-01-28\n---\nStill here.",
+	})
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"hello.md":         "---\ntitle: Disk Hello\ndate: 2024-02-01\n---\nFrom the disk about zeppelins.",
+		"2024/trip.md":     "---\ntitle: Trip\ndate: 2024-03-01\n---\nAirships everywhere.",
+		"notes/readme.txt": "not a post",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := blog.LoadPostsFromDir(dir); err != nil {
+		t.Fatalf("Failed to load posts from disk: %v", err)
+	}
+
+	if len(blog.postList) != 3 {
+		t.Fatalf("Expected 3 posts after merging, got %d", len(blog.postList))
+	}
+	if post := blog.posts["hello"]; post == nil || post.Title != "Disk Hello" {
+		t.Errorf("Expected the post on disk to replace the embedded one, got %+v", post)
+	}
+	if blog.posts["embedded"] == nil || blog.posts["2024-trip"] == nil {
+		t.Errorf("Expected embedded-only and nested disk posts, got %v", blog.posts)
+	}
+
+	if results := blog.search("zeppelins"); len(results) != 1 || results[0].Slug != "hello" {
+		t.Errorf("Expected the disk post in search results, got %v", results)
+	}
+	if results := blog.search("binary"); len(results) != 0 {
+		t.Errorf("Expected the replaced embedded post to leave the index, got %v", results)
+	}
+	if results := blog.search("airships"); len(results) != 1 {
+		t.Errorf("Expected the nested disk post in search results, got %v", results)
+	}
+}
+
+func TestLoadPostsFromMissingDir(t *testing.T) {
+	blog, _ := NewBlog(embed.FS{}, embed.FS{}, embed.FS{})
+	if err := blog.LoadPostsFromDir(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected an error for a missing directory")
+	}
+}
+
+func TestContentDir(t *testing.T) {
+	blog, _ := NewBlogWithConfig(Config{ContentDir: "content/posts/"}, embed.FS{}, embed.FS{}, embed.FS{})
+	blog.blogFS = fstest.MapFS{
+		"content/posts/hello.md":           {Data: []byte("---\ntitle: Hello\ndate: 2024-01-01\n---\nHi.")},
+		"content/posts/2024/long.markdown": {Data: []byte("---\ntitle: Long\ndate: 2024-01-02\n---\nA longer extension.")},
+		"content/posts/notes.txt":          {Data: []byte("not a post")},
+		"blog/old.md":                      {Data: []byte("---\ntitle: Old\ndate: 2024-01-03\n---\nOutside the content dir.")},
+	}
+
+	if blog.Config.ContentDir != "content/posts" {
+		t.Errorf("Expected the content dir to be cleaned, got %q", blog.Config.ContentDir)
+	}
+	if err := blog.LoadPosts(); err != nil {
+		t.Fatalf("Failed to load posts: %v", err)
+	}
+	var ids []string
+	for _, post := range blog.postList {
+		ids = append(ids, post.ID)
+	}
+	if want := []string{"2024-long", "hello"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Expected posts %v from the content dir, got %v", want, ids)
+	}
+	if issues := blog.Lint(); len(issues) != 0 {
+		t.Errorf("Expected lint to check the content dir, got %v", issues)
+	}
+
+	blog, _ = NewBlogWithConfig(Config{ContentDir: "../posts"}, embed.FS{}, embed.FS{}, embed.FS{})
+	if blog.Config.ContentDir != defaultContentDir {
+		t.Errorf("Expected a content dir outside the FS to fall back to %q, got %q", defaultContentDir, blog.Config.ContentDir)
+	}
+}
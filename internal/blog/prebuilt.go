@@ -0,0 +1,46 @@
+package blog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"sort"
+)
+
+// LoadPrebuiltIndex replaces the inverted index with the one stored in the
+// search-index.json that Export writes, typically embedded into a
+// serverless binary. A later LoadPosts keeps this index rather than
+// tokenizing every post again, so the index must come from an export of
+// the same posts.
+func (b *Blog) LoadPrebuiltIndex(fsys fs.FS) error {
+	data, err := fs.ReadFile(fsys, "search-index.json")
+	if err != nil {
+		return fmt.Errorf("failed to read prebuilt search index: %w", err)
+	}
+
+	var searchIndex SearchIndex
+	if err := json.Unmarshal(data, &searchIndex); err != nil {
+		return fmt.Errorf("failed to parse prebuilt search index: %w", err)
+	}
+	if searchIndex.InvertedIndex == nil {
+		searchIndex.InvertedIndex = make(map[string][]string)
+	}
+	// Exports made before posting lists were sorted may list IDs in any order.
+	// Inverting the postings recovers each post's words for remove.
+	terms := make(map[string][]string)
+	for word, ids := range searchIndex.InvertedIndex {
+		sort.Strings(ids)
+		for _, id := range ids {
+			terms[id] = append(terms[id], word)
+		}
+	}
+
+	b.invertedIndex.mu.Lock()
+	b.invertedIndex.index = searchIndex.InvertedIndex
+	b.invertedIndex.titles = make(map[string][]string) // rebuilt by LoadPosts
+	b.invertedIndex.terms = terms
+	b.invertedIndex.mu.Unlock()
+	b.prebuiltIndex = true
+	b.invalidateSearchIndex()
+	return nil
+}
@@ -0,0 +1,99 @@
+package blog
+
+import (
+	"embed"
+	"os"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadPrebuiltIndex(t *testing.T) {
+	files := map[string]string{
+		"go.md":     "---\ntitle: Learning Go\ndate: 2024-01-01\ntags: go\n---\nNotes on programming in Go.",
+		"pasta.md":  "---\ntitle: Cooking Pasta\ndate: 2024-01-02\n---\nA recipe for pasta and notes on sauce.",
+		"travel.md": "---\ntitle: Travel\ndate: 2024-01-03\n---\nTrip notes from Rome, with pasta.",
+	}
+	fresh := newTestBlog(t, files)
+	distDir := t.TempDir()
+	fresh.Export(distDir)
+
+	root := os.DirFS("../..")
+	prebuilt, _ := NewBlog(root, root, embed.FS{})
+	if err := prebuilt.LoadPrebuiltIndex(os.DirFS(distDir)); err != nil {
+		t.Fatalf("Failed to load prebuilt index: %v", err)
+	}
+	mapFS := fstest.MapFS{}
+	for name, content := range files {
+		mapFS["blog/"+name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	prebuilt.blogFS = mapFS
+	if err := prebuilt.LoadPosts(); err != nil {
+		t.Fatalf("Failed to load posts: %v", err)
+	}
+
+	ids := func(posts []*Post) []string {
+		var result []string
+		for _, post := range posts {
+			result = append(result, post.ID)
+		}
+		return result
+	}
+	for _, query := range []string{"notes", "pasta", "notes pasta", "rome", "go", "missing"} {
+		want := ids(fresh.search(query))
+		if got := ids(prebuilt.search(query)); !reflect.DeepEqual(got, want) {
+			t.Errorf("Query %q: expected %v from the prebuilt index, got %v", query, want, got)
+		}
+	}
+
+	prebuilt.RemovePost("pasta")
+	if got := ids(prebuilt.search("pasta")); !reflect.DeepEqual(got, []string{"travel"}) {
+		t.Errorf("Expected a removed post to leave the prebuilt index, got %v", got)
+	}
+	if ids, ok := prebuilt.invertedIndex.index["sauce"]; ok {
+		t.Errorf("Expected words only the removed post used to be dropped, got %v", ids)
+	}
+
+	if err := prebuilt.LoadPrebuiltIndex(fstest.MapFS{}); err == nil {
+		t.Errorf("Expected an error when search-index.json is missing")
+	}
+}
+
+// BenchmarkLoadPosts compares loading posts with a prebuilt index against
+// tokenizing every post to rebuild it.
+func BenchmarkLoadPosts(b *testing.B) {
+	files := syntheticPosts(500)
+	distDir := b.TempDir()
+	if err := newTestBlog(b, files).Export(distDir); err != nil {
+		b.Fatal(err)
+	}
+	mapFS := fstest.MapFS{}
+	for name, content := range files {
+		mapFS["blog/"+name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	root := os.DirFS("../..")
+
+	for _, prebuilt := range []bool{false, true} {
+		name := "rebuild"
+		if prebuilt {
+			name = "prebuilt"
+		}
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				blog, _ := NewBlog(root, root, embed.FS{})
+				blog.blogFS = mapFS
+				b.StartTimer()
+				if prebuilt {
+					if err := blog.LoadPrebuiltIndex(os.DirFS(distDir)); err != nil {
+						b.Fatal(err)
+					}
+				}
+				if err := blog.LoadPosts(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package blog
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+)
+
+// minPrecompressSize is the size below which a gzip variant would save
+// less than the packet or so it costs to serve.
+const minPrecompressSize = 1400
+
+// precompressedExts are the text formats an export writes gzip variants
+// of. Images and fonts are compressed already.
+var precompressedExts = map[string]bool{
+	".html":        true,
+	".css":         true,
+	".js":          true,
+	".json":        true,
+	".xml":         true,
+	".svg":         true,
+	".txt":         true,
+	".webmanifest": true,
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// writeExportFile streams write into the file at path. With precompress
+// set, a gzip-compressed copy is streamed into path.gz at the same time
+// for hosts that serve precompressed files; it is removed again when the
+// file turns out smaller than minPrecompressSize.
+func writeExportFile(path string, precompress bool, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	buffered := bufio.NewWriter(f)
+	counter := &countingWriter{w: buffered}
+	var w io.Writer = counter
+
+	var gzFile *os.File
+	var gz *gzip.Writer
+	if precompress {
+		if gzFile, err = os.Create(path + ".gz"); err != nil {
+			return err
+		}
+		defer gzFile.Close()
+		gz, _ = gzip.NewWriterLevel(gzFile, gzip.BestCompression)
+		w = io.MultiWriter(counter, gz)
+	}
+
+	if err := write(w); err != nil {
+		return err
+	}
+	if err := buffered.Flush(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if gz == nil {
+		return nil
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	if err := gzFile.Close(); err != nil {
+		return err
+	}
+	if counter.n < minPrecompressSize {
+		return os.Remove(path + ".gz")
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package blog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportPrecompress(t *testing.T) {
+	// Distinct words make the search index big enough to compress too.
+	var words []string
+	for i := range 200 {
+		words = append(words, fmt.Sprintf("word%d", i))
+	}
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\n" + strings.Repeat("Hello, precompressed world. ", 100) + strings.Join(words, " "),
+	})
+
+	distDir := t.TempDir()
+	if err := blog.Export(distDir); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(distDir, "index.html.gz")); err == nil {
+		t.Fatalf("Expected no gzip variants without precompress")
+	}
+
+	blog.Config.Precompress = true
+	if err := blog.Export(distDir); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	for _, name := range []string{"index.html", "post/hello/index.html", "search-index.json"} {
+		original, err := os.ReadFile(filepath.Join(distDir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		compressed, err := os.ReadFile(filepath.Join(distDir, filepath.FromSlash(name)+".gz"))
+		if err != nil {
+			t.Fatalf("Expected a gzip variant of %s: %v", name, err)
+		}
+		if len(compressed) >= len(original) {
+			t.Errorf("Expected %s.gz to be smaller than the original, got %d >= %d bytes", name, len(compressed), len(original))
+		}
+		zr, err := gzip.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			t.Fatalf("Invalid gzip variant of %s: %v", name, err)
+		}
+		decompressed, err := io.ReadAll(zr)
+		if err != nil || !bytes.Equal(decompressed, original) {
+			t.Errorf("Expected %s.gz to decompress to the original, got %v", name, err)
+		}
+	}
+
+	// Small files and formats that are compressed already get no variant.
+	filepath.WalkDir(distDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !strings.HasSuffix(path, ".gz") {
+			return err
+		}
+		original, _ := os.Stat(strings.TrimSuffix(path, ".gz"))
+		if original == nil || original.Size() < minPrecompressSize || !precompressedExts[filepath.Ext(strings.TrimSuffix(path, ".gz"))] {
+			t.Errorf("Unexpected gzip variant %s", path)
+		}
+		return nil
+	})
+	if _, err := os.Stat(filepath.Join(distDir, "robots.txt.gz")); err == nil {
+		t.Errorf("Expected no gzip variant of the small robots.txt")
+	}
+}
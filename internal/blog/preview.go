@@ -0,0 +1,38 @@
+package blog
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+)
+
+// previewSecretEnv names the environment variable holding the key that
+// signs draft preview links. Without it, drafts cannot be previewed.
+const previewSecretEnv = "PREVIEW_SECRET"
+
+// previewToken signs slug with the preview secret.
+func (b *Blog) previewToken(slug string) string {
+	mac := hmac.New(sha256.New, b.previewSecret)
+	mac.Write([]byte(slug))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// PreviewURL returns the secret link that shows the draft with the given
+// slug on the live server, or "" when no preview secret is configured.
+func (b *Blog) PreviewURL(slug string) string {
+	if len(b.previewSecret) == 0 {
+		return ""
+	}
+	return b.postURL(slug) + "?preview=" + url.QueryEscape(b.previewToken(slug))
+}
+
+// validPreview reports whether r carries the preview token for slug.
+func (b *Blog) validPreview(r *http.Request, slug string) bool {
+	token := r.URL.Query().Get("preview")
+	if len(b.previewSecret) == 0 || token == "" {
+		return false
+	}
+	return hmac.Equal([]byte(token), []byte(b.previewToken(slug)))
+}
@@ -0,0 +1,208 @@
+package blog
+
+import (
+	"embed"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func newPreviewTestBlog(t *testing.T) *Blog {
+	t.Helper()
+	blog := newTestBlog(t, map[string]string{
+		"secret.md":    "---\ntitle: Secret Plans\ndate: 2024-01-27\ndraft: true\n---\nNot ready yet.",
+		"published.md": "---\ntitle: Published\ndate: 2024-01-28\n---\nOut now.",
+	})
+	blog.Config.BaseURL = "https://cenkcorapci.com"
+	blog.previewSecret = []byte("test-secret")
+	return blog
+}
+
+func TestDraftsAreUnpublished(t *testing.T) {
+	blog := newPreviewTestBlog(t)
+
+	if _, ok := blog.posts["secret"]; ok {
+		t.Errorf("Expected draft to be left out of published posts")
+	}
+	if _, ok := blog.drafts["secret"]; !ok {
+		t.Errorf("Expected draft to be kept for previews")
+	}
+	if results := blog.search("secret"); len(results) != 0 {
+		t.Errorf("Expected draft to be left out of search, got %v", results)
+	}
+
+	rec := httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/post/secret/", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for draft without token, got %d", rec.Code)
+	}
+}
+
+func TestDraftPreviewValidToken(t *testing.T) {
+	blog := newPreviewTestBlog(t)
+
+	link := blog.PreviewURL("secret")
+	if !strings.HasPrefix(link, "https://cenkcorapci.com/post/secret/?preview=") {
+		t.Fatalf("Unexpected preview URL %q", link)
+	}
+
+	rec := httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, strings.TrimPrefix(link, blog.Config.BaseURL), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 with a valid token, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "Secret Plans") {
+		t.Errorf("Expected draft content in preview")
+	}
+	if rec.Header().Get("X-Robots-Tag") != "noindex" {
+		t.Errorf("Expected preview to be marked noindex")
+	}
+	if views := blog.views.snapshot()["secret"]; views != 0 {
+		t.Errorf("Expected previews not to count as views, got %d", views)
+	}
+}
+
+func TestDraftPreviewWrongToken(t *testing.T) {
+	blog := newPreviewTestBlog(t)
+	other := blog.previewToken("published")
+
+	for _, target := range []string{
+		"/post/secret/?preview=wrong",
+		"/post/secret/?preview=" + other,
+	} {
+		rec := httptest.NewRecorder()
+		blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s: expected status 404, got %d", target, rec.Code)
+		}
+	}
+
+	blog.previewSecret = nil
+	if link := blog.PreviewURL("secret"); link != "" {
+		t.Errorf("Expected no preview URL without a secret, got %q", link)
+	}
+	rec := httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/post/secret/?preview=", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 without a secret, got %d", rec.Code)
+	}
+}
+
+func TestPreviewParamIgnoredForPublishedPosts(t *testing.T) {
+	blog := newPreviewTestBlog(t)
+
+	for _, target := range []string{"/post/published/?preview=wrong", "/post/published/"} {
+		rec := httptest.NewRecorder()
+		blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s: expected status 200, got %d", target, rec.Code)
+		}
+		if rec.Header().Get("X-Robots-Tag") != "" {
+			t.Errorf("GET %s: expected published post to stay indexable", target)
+		}
+	}
+}
+
+func TestScheduledPosts(t *testing.T) {
+	files := fstest.MapFS{
+		"blog/past.md":   {Data: []byte("---\ntitle: Past Post\ndate: 2024-01-27\n---\nAlready out.")},
+		"blog/future.md": {Data: []byte("---\ntitle: Future Post\ndate: 2024-03-01\n---\nComing soon.")},
+	}
+	load := func(config Config) *Blog {
+		root := os.DirFS("../..")
+		blog, _ := NewBlogWithConfig(config, root, root, embed.FS{})
+		blog.now = func() time.Time { return time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC) }
+		blog.previewSecret = []byte("test-secret")
+		blog.blogFS = files
+		if err := blog.LoadPosts(); err != nil {
+			t.Fatalf("Failed to load posts: %v", err)
+		}
+		return blog
+	}
+
+	blog := load(Config{})
+	if _, ok := blog.posts["past"]; !ok {
+		t.Errorf("Expected the past-dated post to be published")
+	}
+	if _, ok := blog.posts["future"]; ok || len(blog.postList) != 1 {
+		t.Errorf("Expected the future-dated post to be left out, got %v", blog.postList)
+	}
+	if results := blog.search("soon"); len(results) != 0 {
+		t.Errorf("Expected the future-dated post to be left out of search, got %v", results)
+	}
+	if feed := string(blog.rssXML()); strings.Contains(feed, "Future Post") || !strings.Contains(feed, "Past Post") {
+		t.Errorf("Expected only the past-dated post in the feed, got %s", feed)
+	}
+
+	router := blog.Router()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/post/future/", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a scheduled post, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, strings.TrimPrefix(blog.PreviewURL("future"), blog.Config.BaseURL), nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Robots-Tag") != "noindex" {
+		t.Errorf("Expected a scheduled post to be previewable like a draft, got %d", rec.Code)
+	}
+
+	later := &Post{ID: "later", Title: "Later", Date: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}
+	if err := blog.AddPost(later); err != nil || blog.posts["later"] != nil || blog.drafts["later"] == nil {
+		t.Errorf("Expected AddPost to hold back a future-dated post, got %v", err)
+	}
+
+	if blog := load(Config{PreviewFuture: true}); blog.posts["future"] == nil || len(blog.postList) != 2 {
+		t.Errorf("Expected preview_future to publish the future-dated post, got %v", blog.postList)
+	}
+}
+
+func TestScheduledPostsPublishWithoutReload(t *testing.T) {
+	root := os.DirFS("../..")
+	blog, _ := NewBlogWithConfig(Config{}, root, root, embed.FS{})
+	clock := time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC)
+	blog.now = func() time.Time { return clock }
+	blog.blogFS = fstest.MapFS{
+		"blog/past.md":   {Data: []byte("---\ntitle: Past Post\ndate: 2024-01-27\n---\nAlready out.")},
+		"blog/future.md": {Data: []byte("---\ntitle: Future Post\ndate: 2024-03-01\n---\nComing soon.")},
+		"blog/later.md":  {Data: []byte("---\ntitle: Later Post\ndate: 2024-06-01\n---\nMuch later.")},
+	}
+	if err := blog.LoadPosts(); err != nil {
+		t.Fatalf("Failed to load posts: %v", err)
+	}
+	router := blog.Router()
+
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+	if rec := get("/post/future/"); rec.Code != http.StatusNotFound {
+		t.Fatalf("Expected status 404 before the post's date, got %d", rec.Code)
+	}
+
+	clock = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	if rec := get("/post/future/"); rec.Code != http.StatusOK || rec.Header().Get("X-Robots-Tag") != "" {
+		t.Errorf("Expected the post to be published once its date passed, got %d", rec.Code)
+	}
+	if rec := get("/rss.xml"); !strings.Contains(rec.Body.String(), "Future Post") || strings.Contains(rec.Body.String(), "Later Post") {
+		t.Errorf("Expected only the due post in the feed, got %s", rec.Body.String())
+	}
+	if rec := get("/api/search?q=soon"); !strings.Contains(rec.Body.String(), "future") {
+		t.Errorf("Expected the published post in search, got %s", rec.Body.String())
+	}
+	if len(blog.postList) != 2 || blog.postList[0].ID != "future" || blog.drafts["future"] != nil {
+		t.Errorf("Expected the published post first in the listing, got %v", blog.postList)
+	}
+	if rec := get("/post/later/"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected the later post to stay scheduled, got %d", rec.Code)
+	}
+
+	clock = time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)
+	if rec := get("/api/posts"); !strings.Contains(rec.Body.String(), "Later Post") {
+		t.Errorf("Expected the later post listed once its date passed, got %s", rec.Body.String())
+	}
+}
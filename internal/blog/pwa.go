@@ -0,0 +1,169 @@
+package blog
+
+import (
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// WebManifest is the web app manifest that makes the blog installable as
+// a progressive web app.
+type WebManifest struct {
+	Name            string            `json:"name"`
+	ShortName       string            `json:"short_name"`
+	Description     string            `json:"description,omitempty"`
+	StartURL        string            `json:"start_url"`
+	Scope           string            `json:"scope"`
+	Display         string            `json:"display"`
+	BackgroundColor string            `json:"background_color"`
+	ThemeColor      string            `json:"theme_color"`
+	Icons           []WebManifestIcon `json:"icons"`
+}
+
+// WebManifestIcon is an app icon listed in the web app manifest.
+type WebManifestIcon struct {
+	Src   string `json:"src"`
+	Sizes string `json:"sizes"`
+	Type  string `json:"type"`
+}
+
+const (
+	// maxShortNameLength is the length at which launchers start truncating
+	// app names, so longer blog names are shortened to their first word.
+	maxShortNameLength = 12
+	// maxCachedPosts is how many recently viewed posts the service worker
+	// keeps for offline reading.
+	maxCachedPosts = 20
+	// pwaColor matches the default dark theme's background.
+	pwaColor = "#000000"
+)
+
+// webManifest describes the blog as an installable app. Icons are the
+// static files named icon.*, resolved through the asset manifest.
+func (b *Blog) webManifest(assets map[string]string) WebManifest {
+	shortName := b.Config.BlogName
+	if utf8.RuneCountInString(shortName) > maxShortNameLength {
+		if fields := strings.Fields(shortName); len(fields) > 0 {
+			shortName = fields[0]
+		}
+	}
+
+	icons := []WebManifestIcon{}
+	for _, name := range b.staticFileNames() {
+		if strings.TrimSuffix(name, filepath.Ext(name)) != "icon" {
+			continue
+		}
+		contentType := staticContentTypes[filepath.Ext(name)]
+		if !strings.HasPrefix(contentType, "image/") {
+			continue
+		}
+		sizes := "any"
+		if contentType != "image/svg+xml" {
+			sizes = "512x512"
+		}
+		icons = append(icons, WebManifestIcon{Src: assetPath(assets, name), Sizes: sizes, Type: contentType})
+	}
+
+	return WebManifest{
+		Name:            b.Config.BlogName,
+		ShortName:       shortName,
+		Description:     b.Config.Introduction,
+		StartURL:        "/",
+		Scope:           "/",
+		Display:         "standalone",
+		BackgroundColor: pwaColor,
+		ThemeColor:      pwaColor,
+		Icons:           icons,
+	}
+}
+
+// staticFileNames lists the files shipped under static/, leaving out the
+// JavaScript tests that live next to the scripts.
+func (b *Blog) staticFileNames() []string {
+	var names []string
+	entries, _ := fs.ReadDir(b.staticFS, "static")
+	for _, entry := range entries {
+		if !entry.IsDir() && !strings.HasSuffix(entry.Name(), ".test.js") {
+			names = append(names, entry.Name())
+		}
+	}
+	return names
+}
+
+// serviceWorkerJS returns the service worker script. It precaches the home
+// page and static assets under a cache named after their contents, so
+// changed assets replace the old cache. Pages are fetched network first,
+// and recently viewed posts are kept for offline reading.
+func (b *Blog) serviceWorkerJS(assets map[string]string) []byte {
+	precache := []string{"/"}
+	version := ""
+	for _, name := range b.staticFileNames() {
+		precache = append(precache, assetPath(assets, name))
+		version += name + b.staticETags[name]
+	}
+	urls, _ := json.Marshal(precache)
+
+	var sw strings.Builder
+	sw.WriteString("const CACHE = 'blog-" + contentHash([]byte(version)) + "';\n")
+	sw.WriteString("const POSTS_CACHE = 'blog-posts';\n")
+	sw.WriteString("const PRECACHE = " + string(urls) + ";\n")
+	sw.WriteString("const MAX_POSTS = " + strconv.Itoa(maxCachedPosts) + ";\n")
+	sw.WriteString(serviceWorkerBody)
+	return []byte(sw.String())
+}
+
+const serviceWorkerBody = `
+self.addEventListener('install', event => {
+    event.waitUntil(caches.open(CACHE).then(cache => cache.addAll(PRECACHE)).then(() => self.skipWaiting()));
+});
+
+self.addEventListener('activate', event => {
+    event.waitUntil(caches.keys()
+        .then(keys => Promise.all(keys.filter(key => key !== CACHE && key !== POSTS_CACHE).map(key => caches.delete(key))))
+        .then(() => self.clients.claim()));
+});
+
+async function rememberPost(request, response) {
+    const cache = await caches.open(POSTS_CACHE);
+    await cache.put(request, response);
+    const keys = await cache.keys();
+    for (const key of keys.slice(0, Math.max(keys.length - MAX_POSTS, 0))) {
+        await cache.delete(key);
+    }
+}
+
+self.addEventListener('fetch', event => {
+    const url = new URL(event.request.url);
+    if (event.request.method !== 'GET' || url.origin !== self.location.origin) return;
+
+    if (url.pathname.startsWith('/static/')) {
+        event.respondWith(caches.match(event.request).then(cached => cached || fetch(event.request)));
+        return;
+    }
+
+    event.respondWith(fetch(event.request).then(response => {
+        if (response.ok && url.pathname.startsWith('/post/')) {
+            event.waitUntil(rememberPost(event.request, response.clone()));
+        }
+        return response;
+    }).catch(() => caches.match(event.request).then(cached =>
+        cached || (event.request.mode === 'navigate' ? caches.match('/') : Response.error()))));
+});
+`
+
+func (b *Blog) handleWebManifest(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", staticContentTypes[".webmanifest"])
+	writeJSON(w, http.StatusOK, b.webManifest(nil))
+}
+
+// handleServiceWorker serves the service worker from the root so its scope
+// covers the whole site.
+func (b *Blog) handleServiceWorker(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", staticContentTypes[".js"])
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(b.serviceWorkerJS(nil))
+}
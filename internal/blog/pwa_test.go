@@ -0,0 +1,81 @@
+package blog
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWebManifest(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHi.",
+	})
+
+	rec := httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/manifest.webmanifest", nil))
+	if got := rec.Header().Get("Content-Type"); got != "application/manifest+json" {
+		t.Errorf("Expected Content-Type application/manifest+json, got %q", got)
+	}
+
+	var manifest WebManifest
+	if err := json.Unmarshal(rec.Body.Bytes(), &manifest); err != nil {
+		t.Fatalf("Failed to parse manifest %q: %v", rec.Body.String(), err)
+	}
+	if manifest.Name != blog.Config.BlogName || manifest.ShortName == "" {
+		t.Errorf("Expected name %q and a short name, got %q and %q", blog.Config.BlogName, manifest.Name, manifest.ShortName)
+	}
+	if manifest.StartURL != "/" || manifest.Display != "standalone" {
+		t.Errorf("Expected start_url / and standalone display, got %q and %q", manifest.StartURL, manifest.Display)
+	}
+	if len(manifest.Icons) == 0 || manifest.Icons[0].Src != "/static/icon.svg" {
+		t.Fatalf("Expected the static icon in the manifest, got %+v", manifest.Icons)
+	}
+
+	rec = httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, manifest.Icons[0].Src, nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected the icon to be served, got %d", rec.Code)
+	}
+}
+
+func TestWebManifestShortName(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHi.",
+	})
+
+	blog.Config.BlogName = "Notes"
+	if got := blog.webManifest(nil).ShortName; got != "Notes" {
+		t.Errorf("Expected a short blog name to be kept, got %q", got)
+	}
+	blog.Config.BlogName = "Adventures in Distributed Systems"
+	if got := blog.webManifest(nil).ShortName; got != "Adventures" {
+		t.Errorf("Expected a long blog name to be shortened to its first word, got %q", got)
+	}
+}
+
+func TestExportedServiceWorkerPrecachesHashedAssets(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHi.",
+	})
+
+	files := blog.ExportFiles()
+	sw := string(files["sw.js"])
+	if !strings.Contains(sw, `"/"`) {
+		t.Errorf("Expected the home page to be precached, got %q", sw)
+	}
+	for name := range files {
+		if strings.HasPrefix(name, "static/") && !strings.Contains(name, ".test.") && !strings.Contains(sw, `"/`+name+`"`) {
+			t.Errorf("Expected %s to be precached", name)
+		}
+	}
+
+	var manifest WebManifest
+	if err := json.Unmarshal(files["manifest.webmanifest"], &manifest); err != nil {
+		t.Fatalf("Failed to parse exported manifest: %v", err)
+	}
+	if len(manifest.Icons) == 0 || files[strings.TrimPrefix(manifest.Icons[0].Src, "/")] == nil {
+		t.Errorf("Expected the manifest icons to point at exported files, got %+v", manifest.Icons)
+	}
+}
@@ -0,0 +1,120 @@
+package blog
+
+import (
+	"container/list"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxIdleBuckets is how many clients rateLimiter tracks before it forgets
+// those whose buckets have filled up again.
+const maxIdleBuckets = 10000
+
+// rateLimiter is a per-client token bucket: each client may make burst
+// requests at once, refilled at rate requests per second.
+type rateLimiter struct {
+	rate       float64
+	burst      float64
+	trustProxy bool
+	now        func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*list.Element // of *tokenBucket
+	idle    *list.List               // buckets, least recently used at the back
+}
+
+type tokenBucket struct {
+	client string
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int, trustProxy bool) *rateLimiter {
+	return &rateLimiter{
+		rate:       rate,
+		burst:      float64(burst),
+		trustProxy: trustProxy,
+		now:        time.Now,
+		buckets:    make(map[string]*list.Element),
+		idle:       list.New(),
+	}
+}
+
+// allow takes a token from client's bucket. When the bucket is empty it
+// returns false and how long until the next token.
+func (l *rateLimiter) allow(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	elem, ok := l.buckets[client]
+	if ok {
+		l.idle.MoveToFront(elem)
+	} else {
+		if len(l.buckets) >= maxIdleBuckets {
+			l.forgetFull(now)
+		}
+		elem = l.idle.PushFront(&tokenBucket{client: client, tokens: l.burst, last: now})
+		l.buckets[client] = elem
+	}
+	bucket := elem.Value.(*tokenBucket)
+	bucket.tokens = math.Min(l.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// forgetFull drops buckets that have refilled by now, since a new bucket
+// for their client starts out the same. Any bucket unused for burst/rate
+// seconds is full, so only the least recently used ones are checked, and
+// each bucket is dropped at most once.
+func (l *rateLimiter) forgetFull(now time.Time) {
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+	for back := l.idle.Back(); back != nil; back = l.idle.Back() {
+		bucket := back.Value.(*tokenBucket)
+		if now.Sub(bucket.last) < refill {
+			return
+		}
+		l.idle.Remove(back)
+		delete(l.buckets, bucket.client)
+	}
+}
+
+// clientIP returns the address r came from. With trustProxy it is the
+// last X-Forwarded-For entry, the one appended by the proxy in front of
+// the server; clients can forge the earlier ones.
+func (l *rateLimiter) clientIP(r *http.Request) string {
+	if l.trustProxy {
+		forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+		if ip := strings.TrimSpace(forwarded[len(forwarded)-1]); ip != "" {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// limit answers 429 Too Many Requests, as an APIError with a Retry-After
+// in whole seconds, to clients that have used up their bucket.
+func (l *rateLimiter) limit(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if ok, wait := l.allow(l.clientIP(r)); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			writeJSONError(w, http.StatusTooManyRequests, "too many requests, retry later")
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,106 @@
+package blog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimitSearchAPI(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHello servers.",
+	})
+	blog.Config.RateLimit = 1
+	blog.Config.RateBurst = 3
+	router := blog.Router()
+
+	search := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/search?q=servers", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := 0; i < 3; i++ {
+		if rec := search("192.0.2.1:1234"); rec.Code != http.StatusOK {
+			t.Fatalf("Expected request %d within the burst to succeed, got %d", i+1, rec.Code)
+		}
+	}
+	rec := search("192.0.2.1:5678")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 past the burst, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Expected Retry-After: 1, got %q", got)
+	}
+	if rec := search("192.0.2.2:1234"); rec.Code != http.StatusOK {
+		t.Errorf("Expected another client to have its own bucket, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected pages not to be rate limited, got %d", rec.Code)
+	}
+}
+
+func TestRateLimiterRefills(t *testing.T) {
+	now := time.Date(2024, 1, 27, 0, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter(2, 1, false)
+	limiter.now = func() time.Time { return now }
+
+	if ok, _ := limiter.allow("a"); !ok {
+		t.Fatal("Expected the first request to be allowed")
+	}
+	ok, wait := limiter.allow("a")
+	if ok || wait != 500*time.Millisecond {
+		t.Fatalf("Expected a 500ms wait on an empty bucket, got %v, %v", ok, wait)
+	}
+	now = now.Add(500 * time.Millisecond)
+	if ok, _ := limiter.allow("a"); !ok {
+		t.Error("Expected a request to be allowed once a token refilled")
+	}
+}
+
+func TestRateLimiterForgetsRefilledClients(t *testing.T) {
+	now := time.Date(2024, 1, 27, 0, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter(1, 2, false)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < maxIdleBuckets-1; i++ {
+		limiter.allow(strconv.Itoa(i))
+	}
+	// Two seconds refill every bucket so far; "busy" is still draining.
+	now = now.Add(2 * time.Second)
+	limiter.allow("busy")
+	limiter.allow("busy")
+
+	if limiter.allow("new"); len(limiter.buckets) != 2 || limiter.idle.Len() != 2 {
+		t.Fatalf("Expected only the busy and new clients tracked, got %d", len(limiter.buckets))
+	}
+	if ok, _ := limiter.allow("busy"); ok {
+		t.Error("Expected the busy client to keep its drained bucket")
+	}
+}
+
+func TestRateLimiterClientIP(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/search", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.9, 198.51.100.7")
+
+	if got := newRateLimiter(1, 1, false).clientIP(req); got != "10.0.0.1" {
+		t.Errorf("Expected X-Forwarded-For to be ignored by default, got %q", got)
+	}
+	if got := newRateLimiter(1, 1, true).clientIP(req); got != "198.51.100.7" {
+		t.Errorf("Expected the address the proxy appended, got %q", got)
+	}
+	req.Header.Del("X-Forwarded-For")
+	if got := newRateLimiter(1, 1, true).clientIP(req); got != "10.0.0.1" {
+		t.Errorf("Expected RemoteAddr without X-Forwarded-For, got %q", got)
+	}
+}
@@ -0,0 +1,92 @@
+package blog
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadRedirects reads a YAML map from old post slugs to their new location,
+// either another slug or a path such as /archive/. Requests for an old slug
+// are answered with a 301 by the live server, and the export writes a
+// redirecting page in its place; a published post with the old slug always
+// wins. A missing file is not an error.
+func (b *Blog) LoadRedirects(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read redirects: %w", err)
+	}
+
+	var entries map[string]string
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("failed to parse redirects %s: %w", path, err)
+	}
+
+	redirects := make(map[string]string, len(entries))
+	for from, to := range entries {
+		slug := strings.Trim(strings.TrimPrefix(strings.Trim(from, "/"), "post/"), "/")
+		if slug == "" || to == "" {
+			slog.Warn("Ignoring incomplete redirect", "from", from, "to", to)
+			continue
+		}
+		redirects[slug] = redirectTarget(to)
+	}
+	b.redirects = redirects
+	return nil
+}
+
+// redirectTarget turns a bare slug into its post path and leaves paths and
+// absolute URLs alone.
+func redirectTarget(to string) string {
+	if strings.HasPrefix(to, "/") || strings.Contains(to, "://") {
+		return to
+	}
+	return "/post/" + strings.Trim(to, "/") + "/"
+}
+
+// redirectHTML renders the page the static export leaves at an old post
+// path, pointing browsers and crawlers at target.
+func (b *Blog) redirectHTML(target string) []byte {
+	if strings.HasPrefix(target, "/") {
+		target = b.Config.BaseURL + target
+	}
+	target = html.EscapeString(target)
+	return []byte(fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<title>Redirecting…</title>
+<link rel="canonical" href="%[1]s">
+<meta name="robots" content="noindex">
+<meta http-equiv="refresh" content="0; url=%[1]s">
+</head>
+<body>
+<p>This post has moved to <a href="%[1]s">%[1]s</a>.</p>
+</body>
+</html>
+`, target))
+}
+
+// redirect answers a request for an old post slug with a 301 to its new
+// location, reporting whether slug had one.
+func (b *Blog) redirect(w http.ResponseWriter, r *http.Request, slug string) bool {
+	target, ok := b.redirects[slug]
+	if !ok {
+		return false
+	}
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+	http.Redirect(w, r, target, http.StatusMovedPermanently)
+	return true
+}
@@ -0,0 +1,77 @@
+package blog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newRedirectTestBlog(t *testing.T) *Blog {
+	t.Helper()
+	blog := newTestBlog(t, map[string]string{
+		"new-name.md": "---\ntitle: New Name\ndate: 2024-01-27\n---\nMoved here.",
+	})
+	blog.Config.BaseURL = "https://cenkcorapci.com"
+	path := filepath.Join(t.TempDir(), "redirects.yaml")
+	os.WriteFile(path, []byte("old-name: new-name\n/post/older-name/: /archive/\nnew-name: elsewhere\n"), 0644)
+	if err := blog.LoadRedirects(path); err != nil {
+		t.Fatalf("Failed to load redirects: %v", err)
+	}
+	return blog
+}
+
+func TestLoadRedirectsMissingFile(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHi.",
+	})
+	if err := blog.LoadRedirects(filepath.Join(t.TempDir(), "redirects.yaml")); err != nil {
+		t.Errorf("Expected a missing redirects file to be ignored, got %v", err)
+	}
+}
+
+func TestLiveRedirect(t *testing.T) {
+	blog := newRedirectTestBlog(t)
+	router := blog.Router()
+
+	for target, want := range map[string]string{
+		"/post/old-name/":       "/post/new-name/",
+		"/post/old-name/?ref=x": "/post/new-name/?ref=x",
+		"/post/older-name/":     "/archive/",
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusMovedPermanently {
+			t.Errorf("GET %s: expected status 301, got %d", target, rec.Code)
+		}
+		if loc := rec.Header().Get("Location"); loc != want {
+			t.Errorf("GET %s: expected Location %q, got %q", target, want, loc)
+		}
+	}
+
+	// A redirect cannot shadow a published post.
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/post/new-name/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected the published post to be served, got %d", rec.Code)
+	}
+}
+
+func TestExportRedirectPage(t *testing.T) {
+	blog := newRedirectTestBlog(t)
+
+	page := string(blog.ExportFiles()["post/old-name/index.html"])
+	for _, want := range []string{
+		`<meta http-equiv="refresh" content="0; url=https://cenkcorapci.com/post/new-name/">`,
+		`<link rel="canonical" href="https://cenkcorapci.com/post/new-name/">`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Expected %s in redirect page, got %q", want, page)
+		}
+	}
+	if _, ok := blog.ExportFiles()["post/older-name/index.html"]; !ok {
+		t.Errorf("Expected a redirect page for older-name")
+	}
+}
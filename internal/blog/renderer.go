@@ -0,0 +1,37 @@
+package blog
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// Renderer turns the markdown body of a post, without its frontmatter,
+// into HTML. A custom Renderer can post-process the output, for example
+// to point images at a CDN. The table of contents and math detection
+// still come from the blog's own markdown parser.
+type Renderer interface {
+	Render(markdown string) (template.HTML, error)
+}
+
+// goldmarkRenderer is the default Renderer, configured from Config.
+type goldmarkRenderer struct {
+	md goldmark.Markdown
+}
+
+func (r goldmarkRenderer) Render(markdown string) (template.HTML, error) {
+	source := []byte(markdown)
+	return r.renderDoc(source, r.md.Parser().Parse(text.NewReader(source)))
+}
+
+// renderDoc renders a document already parsed from source.
+func (r goldmarkRenderer) renderDoc(source []byte, doc ast.Node) (template.HTML, error) {
+	var buf bytes.Buffer
+	if err := r.md.Renderer().Render(&buf, source, doc); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
@@ -0,0 +1,49 @@
+package blog
+
+import (
+	"embed"
+	"errors"
+	"html/template"
+	"strings"
+	"testing"
+)
+
+// fakeRenderer records what it was asked to render.
+type fakeRenderer struct {
+	got []string
+	err error
+}
+
+func (r *fakeRenderer) Render(markdown string) (template.HTML, error) {
+	r.got = append(r.got, markdown)
+	return template.HTML("<p>rendered " + strings.TrimSpace(markdown) + "</p>"), r.err
+}
+
+func TestCustomRenderer(t *testing.T) {
+	renderer := &fakeRenderer{}
+	blog, _ := NewBlogWithConfig(Config{}, embed.FS{}, embed.FS{}, embed.FS{}, renderer)
+
+	post, err := blog.parsePost("hello.md", "---\ntitle: Hello\ndate: 2024-01-27\n---\n## Intro\nHi.")
+	if err != nil {
+		t.Fatalf("Failed to parse post: %v", err)
+	}
+	if len(renderer.got) != 1 || renderer.got[0] != "## Intro\nHi." {
+		t.Errorf("Expected the renderer to get the body without frontmatter, got %q", renderer.got)
+	}
+	if post.HTMLContent != "<p>rendered ## Intro\nHi.</p>" {
+		t.Errorf("Expected the renderer's HTML, got %q", post.HTMLContent)
+	}
+	if len(post.TOC) != 1 || post.TOC[0].Text != "Intro" {
+		t.Errorf("Expected the TOC to still come from the markdown, got %+v", post.TOC)
+	}
+
+	renderer.err = errors.New("boom")
+	if _, err := blog.parsePost("hello.md", "---\ntitle: Hello\ndate: 2024-01-27\n---\nHi."); !errors.Is(err, renderer.err) {
+		t.Errorf("Expected the renderer's error, got %v", err)
+	}
+
+	blog, _ = NewBlogWithConfig(Config{}, embed.FS{}, embed.FS{}, embed.FS{})
+	if post, _ := blog.parsePost("hello.md", "---\ntitle: Hello\ndate: 2024-01-27\n---\n**Hi**"); !strings.Contains(string(post.HTMLContent), "<strong>Hi</strong>") {
+		t.Errorf("Expected goldmark by default, got %q", post.HTMLContent)
+	}
+}
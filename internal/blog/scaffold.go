@@ -0,0 +1,41 @@
+package blog
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CreatePost writes a new post named after title into dir, typically blog/,
+// with its frontmatter filled in, and returns the file's path. It never
+// overwrites an existing post.
+func CreatePost(dir, title string, now time.Time) (string, error) {
+	title = strings.Join(strings.Fields(title), " ")
+	slug := slugify(title)
+	if slug == "" {
+		return "", errors.New("title must contain letters or digits")
+	}
+
+	path := filepath.Join(dir, slug+".md")
+	content := fmt.Sprintf("---\ntitle: %s\ndate: %s\ntags:\n---\n\n# %s\n\n", title, now.Format("2006-01-02"), title)
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, fs.ErrExist) {
+		return "", fmt.Errorf("%s already exists", path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to create post: %w", err)
+	}
+	if _, err := file.WriteString(content); err != nil {
+		file.Close()
+		return "", fmt.Errorf("failed to write post: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("failed to write post: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,54 @@
+package blog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCreatePost(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 3, 9, 15, 0, 0, 0, time.UTC)
+
+	path, err := CreatePost(dir, "  Hello, Go & Web! ", now)
+	if err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+	if want := filepath.Join(dir, "hello-go-web.md"); path != want {
+		t.Errorf("Expected path %s, got %s", want, path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read created post: %v", err)
+	}
+	want := "---\ntitle: Hello, Go & Web!\ndate: 2024-03-09\ntags:\n---\n\n# Hello, Go & Web!\n\n"
+	if string(data) != want {
+		t.Errorf("Unexpected post content:\n%s", data)
+	}
+
+	// The scaffold must load as a regular post.
+	blog := newTestBlog(t, map[string]string{"hello-go-web.md": string(data)})
+	post := blog.posts["hello-go-web"]
+	if post == nil || post.Title != "Hello, Go & Web!" || !post.Date.Equal(time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC)) || len(post.Tags) != 0 {
+		t.Errorf("Unexpected parsed scaffold %+v", post)
+	}
+}
+
+func TestCreatePostRefusesOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "hello.md")
+	os.WriteFile(existing, []byte("keep me"), 0644)
+
+	if _, err := CreatePost(dir, "Hello", time.Now()); err == nil {
+		t.Fatalf("Expected an error for an existing post")
+	}
+	if data, _ := os.ReadFile(existing); string(data) != "keep me" {
+		t.Errorf("Expected existing post to be left untouched, got %q", data)
+	}
+
+	if _, err := CreatePost(dir, "!!!", time.Now()); err == nil {
+		t.Errorf("Expected an error for a title without letters or digits")
+	}
+}
@@ -0,0 +1,280 @@
+package blog
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// search returns the posts matching query, best matches first as scored by
+// rank. An exact tag match short-circuits the full-text lookup, mirroring
+// static/search.js, and returns the tagged posts newest first.
+func (b *Blog) search(query string) []*Post {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil
+	}
+
+	var tagMatches []*Post
+	for _, post := range b.postList {
+		if hasTag(post, query) {
+			tagMatches = append(tagMatches, post)
+		}
+	}
+	if len(tagMatches) > 0 {
+		return tagMatches
+	}
+
+	words := tokenize(query)
+	if len(words) == 0 {
+		return nil
+	}
+	if len(words) > maxQueryWords {
+		words = words[:maxQueryWords]
+	}
+
+	b.invertedIndex.mu.RLock()
+	defer b.invertedIndex.mu.RUnlock()
+
+	// Words no post contains, such as typos, are dropped rather than
+	// emptying the results; a query made only of them matches nothing.
+	var postings [][]string
+	var known []string
+	for _, word := range words {
+		if postIDs := b.postingsFor(foldCase(word)); len(postIDs) > 0 {
+			postings = append(postings, postIDs)
+			known = append(known, word)
+		}
+	}
+	if len(postings) == 0 {
+		return nil
+	}
+
+	// Starting from the rarest word keeps every intersection small. The
+	// order of the result doesn't matter: postsByID and rank order it.
+	sort.SliceStable(postings, func(i, j int) bool {
+		return len(postings[i]) < len(postings[j])
+	})
+	matchingPostIDs := postings[0]
+	for _, postIDs := range postings[1:] {
+		matchingPostIDs = intersection(matchingPostIDs, postIDs)
+		if len(matchingPostIDs) == 0 {
+			return nil
+		}
+	}
+
+	return b.rank(b.postsByID(matchingPostIDs), known)
+}
+
+// rank sorts posts by relevance to the query words, keeping newest-first
+// order among equal scores. Each word adds Config.TitleWeight to a post
+// whose title contains it and Config.BodyWeight otherwise. The caller must
+// hold the inverted index read lock.
+func (b *Blog) rank(posts []*Post, words []string) []*Post {
+	scores := make(map[string]float64, len(posts))
+	for _, post := range posts {
+		for _, word := range words {
+			if b.inTitle(foldCase(word), post.ID) {
+				scores[post.ID] += b.Config.TitleWeight
+			} else {
+				scores[post.ID] += b.Config.BodyWeight
+			}
+		}
+	}
+	sort.SliceStable(posts, func(i, j int) bool {
+		return scores[posts[i].ID] > scores[posts[j].ID]
+	})
+	return posts
+}
+
+// inTitle reports whether word occurs in the title of the post with the
+// given ID, matching inside title words when InfixSearch is enabled.
+// The caller must hold the inverted index read lock.
+func (b *Blog) inTitle(word, id string) bool {
+	if !b.Config.InfixSearch {
+		ids := b.invertedIndex.titles[word]
+		i := sort.SearchStrings(ids, id)
+		return i < len(ids) && ids[i] == id
+	}
+	for term, ids := range b.invertedIndex.titles {
+		if strings.Contains(term, word) && contains(ids, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxQueryWords bounds the posting lists one search intersects. Later
+// words of a longer query are ignored.
+const maxQueryWords = 32
+
+const (
+	fallbackMinQueryLength = 3
+	fallbackMaxResults     = 10
+)
+
+// searchWithFallback runs search and, when it finds nothing and
+// Config.SearchFallback is enabled, retries with fallbackSearch. The boolean
+// reports whether the results came from the broadened fallback. At most
+// Config.MaxSearchResults posts are returned, best matches first.
+func (b *Blog) searchWithFallback(query string) ([]*Post, bool) {
+	results := b.search(query)
+	broadened := false
+	if len(results) == 0 && b.Config.SearchFallback {
+		results = b.fallbackSearch(query)
+		broadened = len(results) > 0
+	}
+
+	if len(results) > b.Config.MaxSearchResults {
+		results = results[:b.Config.MaxSearchResults]
+	}
+	return results, broadened
+}
+
+// cachedSearch is searchWithFallback with its results cached until the
+// posts or the index change. The last result reports whether they came
+// from the cache. Callers must not modify the returned posts.
+func (b *Blog) cachedSearch(query string) ([]*Post, bool, bool) {
+	key := cacheKey(query)
+
+	c := &b.indexCache
+	c.mu.Lock()
+	if hit, ok := c.results[key]; ok {
+		c.mu.Unlock()
+		return hit.posts, hit.broadened, true
+	}
+	if c.results == nil || len(c.results) >= maxCachedSearches {
+		c.results = make(map[string]cachedResults)
+	}
+	// Results are stored in the map seen now; if the cache is invalidated
+	// while searching, they go to the dropped map and are never served.
+	results := c.results
+	c.mu.Unlock()
+
+	posts, broadened := b.searchWithFallback(query)
+	c.mu.Lock()
+	results[key] = cachedResults{posts: posts, broadened: broadened}
+	c.mu.Unlock()
+	return posts, broadened, false
+}
+
+// cacheKey is the key of query's results in the search cache. Both search
+// and fallbackSearch ignore case and surrounding space, so neither does the
+// key.
+func cacheKey(query string) string {
+	return foldCase(strings.TrimSpace(query))
+}
+
+// fallbackSearch scans post titles and content for query as a plain
+// case-insensitive substring, catching matches that tokenization splits
+// apart (e.g. hyphenated terms). It is bounded to fallbackMaxResults posts.
+func (b *Blog) fallbackSearch(query string) []*Post {
+	query = foldCase(strings.TrimSpace(query))
+	if len([]rune(query)) < fallbackMinQueryLength {
+		return nil
+	}
+
+	var results []*Post
+	for _, post := range b.postList {
+		if strings.Contains(foldCase(post.Title), query) || strings.Contains(foldCase(post.Content), query) {
+			results = append(results, post)
+			if len(results) == fallbackMaxResults {
+				break
+			}
+		}
+	}
+	return results
+}
+
+// postingsFor returns the IDs of posts containing word. With InfixSearch
+// enabled, every indexed term containing word as a substring contributes,
+// which costs a scan of the whole term dictionary per query word.
+// The caller must hold the inverted index read lock.
+func (b *Blog) postingsFor(word string) []string {
+	if !b.Config.InfixSearch {
+		return b.invertedIndex.index[word]
+	}
+
+	var postIDs []string
+	for term, ids := range b.invertedIndex.index {
+		if !strings.Contains(term, word) {
+			continue
+		}
+		for _, id := range ids {
+			if !contains(postIDs, id) {
+				postIDs = append(postIDs, id)
+			}
+		}
+	}
+	return postIDs
+}
+
+// postsByID resolves post IDs to posts, keeping the newest-first order of postList.
+func (b *Blog) postsByID(ids []string) []*Post {
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	var posts []*Post
+	for _, post := range b.postList {
+		if wanted[post.ID] {
+			posts = append(posts, post)
+		}
+	}
+	return posts
+}
+
+const snippetLength = 160
+
+// snippet returns about n characters of content starting shortly before the
+// first occurrence of any of words, or the start of content if none occur.
+func snippet(content string, words []string, n int) string {
+	runes := []rune(content)
+	lower := strings.ToLower(content)
+
+	start := -1
+	for _, word := range words {
+		i := strings.Index(lower, strings.ToLower(word))
+		if i < 0 {
+			continue
+		}
+		if pos := utf8.RuneCountInString(lower[:i]); start < 0 || pos < start {
+			start = pos
+		}
+	}
+
+	from := start - n/4
+	if from < 0 {
+		from = 0
+	}
+	to := from + n
+	if to > len(runes) {
+		to = len(runes)
+	}
+
+	text := strings.Join(strings.Fields(string(runes[from:to])), " ")
+	if from > 0 {
+		text = "…" + text
+	}
+	if to < len(runes) {
+		text += "…"
+	}
+	return text
+}
+
+// intersection returns the IDs in both a and b, in the order of b.
+func intersection(a, b []string) []string {
+	set := make(map[string]bool, len(a))
+	for _, id := range a {
+		set[id] = true
+	}
+
+	var result []string
+	for _, id := range b {
+		if set[id] {
+			result = append(result, id)
+		}
+	}
+	return result
+}
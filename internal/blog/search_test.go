@@ -0,0 +1,216 @@
+package blog
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSearchInfix(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"go.md":   "---\ntitle: Learning Go\ndate: 2024-01-01\n---\nNotes on programming in Go.",
+		"food.md": "---\ntitle: Cooking\ndate: 2024-01-02\n---\nA recipe for pasta.",
+	})
+
+	if results := blog.search("gram"); len(results) != 0 {
+		t.Errorf("Expected no results without infix search, got %d", len(results))
+	}
+
+	blog.Config.InfixSearch = true
+	results := blog.search("gram")
+	if len(results) != 1 || results[0].ID != "go" {
+		t.Fatalf("Expected infix search for 'gram' to match post 'go', got %v", results)
+	}
+}
+
+func TestSearchFallbackHyphenated(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"coop.md": "---\ntitle: Housing\ndate: 2024-01-01\n---\nWe joined a co-operative last year.",
+		"misc.md": "---\ntitle: Misc\ndate: 2024-01-02\n---\nNothing relevant here.",
+	})
+
+	// Neither "o" nor "oper" is an indexed word, so only the substring
+	// scan can find the fragment across the hyphen.
+	if results, broadened := blog.searchWithFallback("o-oper"); len(results) != 0 || broadened {
+		t.Fatalf("Expected no results with the fallback disabled, got %d", len(results))
+	}
+
+	blog.Config.SearchFallback = true
+	results, broadened := blog.searchWithFallback("o-oper")
+	if len(results) != 1 || results[0].ID != "coop" {
+		t.Fatalf("Expected fallback to find 'coop', got %v", results)
+	}
+	if !broadened {
+		t.Errorf("Expected fallback results to be marked as broadened")
+	}
+
+	if _, broadened := blog.searchWithFallback("housing"); broadened {
+		t.Errorf("Expected an indexed match not to be marked as broadened")
+	}
+}
+
+func TestSearchDataPagination(t *testing.T) {
+	files := make(map[string]string)
+	for i := 1; i <= 25; i++ {
+		files[fmt.Sprintf("post-%02d.md", i)] = fmt.Sprintf("---\ntitle: Post %d\ndate: 2024-01-%02d\ntags: go\n---\nAbout gophers.", i, i)
+	}
+	blog := newTestBlog(t, files)
+
+	for _, query := range []string{"go", "gophers"} {
+		data := blog.searchData(query, 2)
+		posts := data["Posts"].([]*Post)
+		if len(posts) != 10 {
+			t.Fatalf("search(%q) page 2: expected 10 posts, got %d", query, len(posts))
+		}
+		// Newest first, so page 2 holds posts 15 down to 6.
+		if posts[0].ID != "post-15" || posts[9].ID != "post-06" {
+			t.Errorf("search(%q) page 2: expected post-15..post-06, got %s..%s", query, posts[0].ID, posts[9].ID)
+		}
+		if data["TotalResults"] != 25 || data["TotalPages"] != 3 || data["CurrentPage"] != 2 {
+			t.Errorf("search(%q): unexpected totals %v results, %v pages, page %v", query, data["TotalResults"], data["TotalPages"], data["CurrentPage"])
+		}
+		if data["PrevPage"] != 1 || data["NextPage"] != 3 {
+			t.Errorf("search(%q): expected prev 1 and next 3, got %v and %v", query, data["PrevPage"], data["NextPage"])
+		}
+	}
+
+	if posts := blog.searchData("go", 3)["Posts"].([]*Post); len(posts) != 5 {
+		t.Errorf("Expected 5 posts on the last page, got %d", len(posts))
+	}
+	if data := blog.searchData("go", 99); data["CurrentPage"] != 3 {
+		t.Errorf("Expected an out-of-range page to clamp to 3, got %v", data["CurrentPage"])
+	}
+
+	rec := httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search/?q=go&page=2", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, "Page 2 of 3") || !strings.Contains(body, `href="/search/?q=go&page=3"`) {
+		t.Errorf("Expected pagination links on the search page")
+	}
+}
+
+func TestSearchEmptyStates(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"go.md": "---\ntitle: Learning Go\ndate: 2024-01-01\n---\nNotes on gophers.",
+	})
+	router := blog.Router()
+	get := func(path string) string {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Body.String()
+	}
+
+	if data := blog.searchData("  ", 1); data["Searched"].(bool) {
+		t.Errorf("Expected a blank query not to count as a search")
+	}
+	body := get("/search/")
+	if !strings.Contains(body, "Enter a search query") || strings.Contains(body, "No results for") {
+		t.Errorf("Expected the initial prompt without a query, got %s", body)
+	}
+
+	if data := blog.searchData("pasta", 1); !data["Searched"].(bool) || data["TotalResults"].(int) != 0 {
+		t.Errorf("Expected a searched query with no results, got %v", data)
+	}
+	body = get("/search/?q=pasta")
+	if !strings.Contains(body, `No results for "pasta".`) || strings.Contains(body, "Enter a search query") {
+		t.Errorf("Expected a no-results message for a query without matches, got %s", body)
+	}
+}
+
+func TestSearchMaxResults(t *testing.T) {
+	files := make(map[string]string)
+	for i := 1; i <= 30; i++ {
+		files[fmt.Sprintf("post-%02d.md", i)] = fmt.Sprintf("---\ntitle: Post %d\ndate: 2024-01-%02d\n---\nAbout gophers.", i, i)
+	}
+	blog := newTestBlog(t, files)
+	blog.Config.MaxSearchResults = 12
+
+	results, _ := blog.searchWithFallback("gophers")
+	if len(results) != 12 || results[0].ID != "post-30" || results[11].ID != "post-19" {
+		t.Fatalf("Expected the 12 newest matches, got %v", results)
+	}
+	if data := blog.searchData("gophers", 1); data["TotalResults"].(int) != 12 || data["TotalPages"].(int) != 2 {
+		t.Errorf("Expected the capped results to be paginated, got %v", data)
+	}
+
+	rec := httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/search?q=gophers", nil))
+	if got := strings.Count(rec.Body.String(), `"slug":`); got != 12 {
+		t.Errorf("Expected the search API to return 12 results, got %d", got)
+	}
+
+	if got := applyConfigDefaults(Config{}).MaxSearchResults; got != 100 {
+		t.Errorf("Expected 100 results by default, got %d", got)
+	}
+}
+
+func TestSearchTitleWeight(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"titled.md": "---\ntitle: Sourdough Basics\ndate: 2024-01-01\n---\nFlour, water and time.",
+		"body.md":   "---\ntitle: Weekend Notes\ndate: 2024-01-02\n---\nBaked sourdough again.",
+	})
+
+	results := blog.search("sourdough")
+	if len(results) != 2 || results[0].ID != "titled" || results[1].ID != "body" {
+		t.Fatalf("Expected the title match to outrank the newer body match, got %v", results)
+	}
+
+	blog.Config.InfixSearch = true
+	if results := blog.search("dough"); len(results) != 2 || results[0].ID != "titled" {
+		t.Errorf("Expected infix title matches to be weighted too, got %v", results)
+	}
+
+	blog.Config.InfixSearch = false
+	blog.Config.TitleWeight = blog.Config.BodyWeight
+	if results := blog.search("sourdough"); len(results) != 2 || results[0].ID != "body" {
+		t.Errorf("Expected equal weights to leave results newest first, got %v", results)
+	}
+
+	blog.RemovePost("titled")
+	if ids := blog.invertedIndex.titles["sourdough"]; len(ids) != 0 {
+		t.Errorf("Expected removing a post to drop its title postings, got %v", ids)
+	}
+
+	if got := applyConfigDefaults(Config{}); got.TitleWeight <= got.BodyWeight {
+		t.Errorf("Expected title matches to weigh more than body matches by default, got %v and %v", got.TitleWeight, got.BodyWeight)
+	}
+}
+
+func TestSearchIgnoresUnknownWords(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"coop.md":  "---\ntitle: Housing\ndate: 2024-01-01\n---\nWe joined a co-operative last year.",
+		"pasta.md": "---\ntitle: Pasta Night\ndate: 2024-01-02\n---\nFresh pasta with tomato sauce.",
+		"sauce.md": "---\ntitle: Sauces\ndate: 2024-01-03\n---\nA tomato sauce for any dish.",
+	})
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"xyzzy pasta", []string{"pasta"}}, // unknown first word
+		{"pasta xyzzy", []string{"pasta"}}, // unknown last word
+		{"xyzzy tomato sauce", []string{"sauce", "pasta"}},
+		{"xyzzy tomato year", nil}, // known words that never meet
+		{"xyzzy", nil},             // only unknown words
+		{"xyzzy plugh", nil},
+		{"co-op", []string{"coop"}}, // "op" is never indexed
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, post := range blog.search(tt.query) {
+			got = append(got, post.ID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("search(%q): expected %v, got %v", tt.query, tt.want, got)
+		}
+	}
+
+	// Intersecting in any order gives the same results.
+	a, b := blog.search("tomato sauce"), blog.search("sauce tomato")
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("Expected word order not to change results, got %v and %v", a, b)
+	}
+}
@@ -0,0 +1,161 @@
+package blog
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// adminSecretEnv names the environment variable holding the secret that
+// admin-only endpoints require in the adminSecretHeader request header.
+// Without it, those endpoints answer 404.
+const (
+	adminSecretEnv    = "ADMIN_SECRET"
+	adminSecretHeader = "X-Admin-Secret"
+)
+
+// maxTrackedQueries bounds the distinct queries searchLog counts, so a
+// client sending random queries can't grow it without limit. Searches for
+// other queries are still logged, just not counted.
+const maxTrackedQueries = 10000
+
+// searchLog records what readers search for on the live server when
+// Config.LogSearches is set: each search as a JSON line written to out,
+// and per-query counts for /api/search-stats.
+type searchLog struct {
+	mu     sync.Mutex
+	out    io.Writer
+	counts map[string]*QueryStat
+	total  int64
+	hits   int64 // searches answered from the results cache
+}
+
+func newSearchLog(out io.Writer) *searchLog {
+	return &searchLog{out: out, counts: make(map[string]*QueryStat)}
+}
+
+// searchLogEntry is one line of the search log.
+type searchLogEntry struct {
+	Time      string `json:"time"`
+	Query     string `json:"query"`
+	Results   int    `json:"results"`
+	Broadened bool   `json:"broadened"` // results came from the substring fallback
+	CacheHit  bool   `json:"cache_hit"` // results came from the search results cache
+}
+
+// QueryStat counts the searches for one normalized query.
+type QueryStat struct {
+	Query     string `json:"query"`
+	Count     int64  `json:"count"`
+	Results   int    `json:"results"`    // found by the latest search
+	CacheHits int64  `json:"cache_hits"` // searches answered from the results cache
+}
+
+// SearchStatsJSON is the response of /api/search-stats.
+type SearchStatsJSON struct {
+	Total     int64       `json:"total"`      // searches recorded since the server started
+	CacheHits int64       `json:"cache_hits"` // of those, answered from the results cache
+	Queries   []QueryStat `json:"queries"`    // most searched first
+}
+
+// SetSearchLog sends the search log to w instead of stdout.
+func (b *Blog) SetSearchLog(w io.Writer) {
+	b.searches.mu.Lock()
+	b.searches.out = w
+	b.searches.mu.Unlock()
+}
+
+// normalizeQuery is the form in which queries are logged and counted, so
+// "Go  Modules" and "go modules" are the same query.
+func normalizeQuery(query string) string {
+	return foldCase(strings.Join(strings.Fields(query), " "))
+}
+
+// recordSearch logs a search and counts its query, when Config.LogSearches
+// is set. Empty queries aren't searches, and queries longer than any the
+// search API accepts aren't worth keeping. cached reports whether the
+// results came from the search results cache.
+func (b *Blog) recordSearch(query string, results int, broadened, cached bool) {
+	query = normalizeQuery(query)
+	if !b.Config.LogSearches || query == "" || len(query) > maxQueryLength {
+		return
+	}
+
+	l := b.searches
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	hit := int64(0)
+	if cached {
+		hit = 1
+	}
+	l.total++
+	l.hits += hit
+	if stat, ok := l.counts[query]; ok {
+		stat.Count++
+		stat.Results = results
+		stat.CacheHits += hit
+	} else if len(l.counts) < maxTrackedQueries {
+		l.counts[query] = &QueryStat{Query: query, Count: 1, Results: results, CacheHits: hit}
+	}
+
+	line, _ := json.Marshal(searchLogEntry{
+		Time:      time.Now().UTC().Format(time.RFC3339),
+		Query:     query,
+		Results:   results,
+		Broadened: broadened,
+		CacheHit:  cached,
+	})
+	if _, err := l.out.Write(append(line, '\n')); err != nil {
+		slog.Warn("Error writing search log", "err", err)
+	}
+}
+
+// topQueries returns the n most searched queries, ties broken
+// alphabetically, and the number of searches recorded.
+func (l *searchLog) topQueries(n int) SearchStatsJSON {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	queries := make([]QueryStat, 0, len(l.counts))
+	for _, stat := range l.counts {
+		queries = append(queries, *stat)
+	}
+	sort.Slice(queries, func(i, j int) bool {
+		if queries[i].Count != queries[j].Count {
+			return queries[i].Count > queries[j].Count
+		}
+		return queries[i].Query < queries[j].Query
+	})
+	if len(queries) > n {
+		queries = queries[:n]
+	}
+	return SearchStatsJSON{Total: l.total, CacheHits: l.hits, Queries: queries}
+}
+
+// validAdminSecret reports whether r carries the admin secret.
+func (b *Blog) validAdminSecret(r *http.Request) bool {
+	secret := r.Header.Get(adminSecretHeader)
+	return secret != "" && subtle.ConstantTimeCompare([]byte(secret), b.adminSecret) == 1
+}
+
+// handleSearchStats lists the most searched queries, up to ?limit=
+// (default and maximum 50). It is off without an admin secret.
+func (b *Blog) handleSearchStats(w http.ResponseWriter, r *http.Request) {
+	if len(b.adminSecret) == 0 {
+		writeJSONError(w, http.StatusNotFound, "search stats are off; set "+adminSecretEnv+" to enable them")
+		return
+	}
+	if !b.validAdminSecret(r) {
+		writeJSONError(w, http.StatusUnauthorized, "missing or wrong "+adminSecretHeader+" header")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, b.searches.topQueries(min(queryInt(r, "limit", maxPageLimit), maxPageLimit)))
+}
@@ -0,0 +1,101 @@
+package blog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSearchStats(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"go.md":    "---\ntitle: Learning Go\ndate: 2024-01-01\n---\nNotes on Go modules.",
+		"pasta.md": "---\ntitle: Cooking\ndate: 2024-01-02\n---\nA recipe for pasta.",
+	})
+	blog.Config.RateBurst = 100
+	blog.adminSecret = []byte("admin-secret")
+	var out bytes.Buffer
+	blog.SetSearchLog(&out)
+	router := blog.Router()
+
+	get := func(target string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	searches := []string{
+		"/api/search?q=go",
+		"/api/search?q=Go",
+		"/search/?q=go",
+		"/search/?q=go&page=2", // the same search, already counted
+		"/api/search?q=pasta",
+		"/api/search?q=missing",
+		"/api/search?q=%20%20",
+		"/search/?q=",
+	}
+
+	for _, target := range searches {
+		get(target, nil)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("Expected nothing logged without log_searches, got %s", out.String())
+	}
+
+	// Start from an empty results cache, so the first search for a query
+	// misses it and repeats, whatever their case, hit it.
+	blog.invalidateSearchIndex()
+	blog.Config.LogSearches = true
+	for _, target := range searches {
+		get(target, nil)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("Expected 5 logged searches, got %d: %s", len(lines), out.String())
+	}
+	var entry searchLogEntry
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil || entry.CacheHit {
+		t.Errorf("Expected the first search to miss the cache, got %s (%v)", lines[0], err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil || entry.Query != "go" || entry.Results != 1 || entry.Time == "" || !entry.CacheHit {
+		t.Errorf("Expected a JSON line with the normalized query, answered from the cache, got %s (%v)", lines[1], err)
+	}
+
+	secret := http.Header{adminSecretHeader: {"admin-secret"}}
+	rec := get("/api/search-stats", secret)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 with the secret, got %d", rec.Code)
+	}
+	var stats SearchStatsJSON
+	if err := json.Unmarshal(rec.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	want := []QueryStat{{"go", 3, 1, 2}, {"missing", 1, 0, 0}, {"pasta", 1, 1, 0}}
+	if stats.Total != 5 || stats.CacheHits != 2 || len(stats.Queries) != len(want) {
+		t.Fatalf("Expected 5 searches, 2 from the cache, over %v, got %+v", want, stats)
+	}
+	for i := range want {
+		if stats.Queries[i] != want[i] {
+			t.Errorf("Expected %+v at %d, got %+v", want[i], i, stats.Queries[i])
+		}
+	}
+	if rec := get("/api/search-stats?limit=1", secret); !strings.Contains(rec.Body.String(), `"queries":[{"query":"go"`) || strings.Contains(rec.Body.String(), "pasta") {
+		t.Errorf("Expected limit to keep only the top query, got %s", rec.Body.String())
+	}
+
+	for _, header := range []http.Header{nil, {adminSecretHeader: {"wrong"}}} {
+		if rec := get("/api/search-stats", header); rec.Code != http.StatusUnauthorized || strings.Contains(rec.Body.String(), "pasta") {
+			t.Errorf("Expected 401 without the right secret, got %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+	blog.adminSecret = nil
+	if rec := get("/api/search-stats", http.Header{adminSecretHeader: {""}}); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without an admin secret configured, got %d", rec.Code)
+	}
+}
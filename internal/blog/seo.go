@@ -0,0 +1,122 @@
+package blog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"time"
+)
+
+// robotsTxt allows all crawlers and, when a base URL is configured, points
+// them at the sitemap.
+func (b *Blog) robotsTxt() []byte {
+	robots := "User-agent: *\nAllow: /\n"
+	if b.Config.BaseURL != "" {
+		robots += fmt.Sprintf("Sitemap: %s/sitemap.xml\n", b.Config.BaseURL)
+	}
+	return []byte(robots)
+}
+
+func (b *Blog) sitemapXML() []byte {
+	var sitemap bytes.Buffer
+	sitemap.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
+	sitemap.WriteString("\n")
+	sitemap.WriteString(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+	sitemap.WriteString("\n")
+
+	// Home page
+	sitemap.WriteString(fmt.Sprintf("\t<url><loc>%s/</loc><changefreq>weekly</changefreq><priority>1.0</priority></url>\n", b.Config.BaseURL))
+
+	// Search page
+	sitemap.WriteString(fmt.Sprintf("\t<url><loc>%s/search/</loc><changefreq>monthly</changefreq><priority>0.3</priority></url>\n", b.Config.BaseURL))
+
+	// Archive page
+	sitemap.WriteString(fmt.Sprintf("\t<url><loc>%s/archive/</loc><changefreq>weekly</changefreq><priority>0.5</priority></url>\n", b.Config.BaseURL))
+
+	// Posts
+	for _, post := range b.postList {
+		sitemap.WriteString(fmt.Sprintf("\t<url><loc>%s</loc><lastmod>%s</lastmod><changefreq>monthly</changefreq><priority>0.8</priority></url>\n",
+			b.postURL(post.Slug), post.Updated.Format("2006-01-02")))
+	}
+
+	sitemap.WriteString(`</urlset>`)
+	return sitemap.Bytes()
+}
+
+// postURL is the canonical URL of the post with the given slug. It is
+// absolute when BaseURL is set and site-relative otherwise.
+func (b *Blog) postURL(slug string) string {
+	return b.Config.BaseURL + "/post/" + slug + "/"
+}
+
+// SlugJSON is an entry of slugs.json, listing every post's canonical URL
+// for clients that route or prefetch without the whole search index.
+type SlugJSON struct {
+	Slug  string `json:"slug"`
+	URL   string `json:"url"`
+	Title string `json:"title"`
+	Date  string `json:"date"`
+}
+
+// slugList returns every published post, newest first.
+func (b *Blog) slugList() []SlugJSON {
+	slugs := make([]SlugJSON, 0, len(b.postList))
+	for _, post := range b.postList {
+		slugs = append(slugs, SlugJSON{
+			Slug:  post.Slug,
+			URL:   b.postURL(post.Slug),
+			Title: post.Title,
+			Date:  post.Date.Format("2006-01-02"),
+		})
+	}
+	return slugs
+}
+
+// jsonLDPerson is a schema.org Person, used for both the author and the
+// publisher since the blog has a single author.
+type jsonLDPerson struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
+
+// BlogPostingJSONLD is the schema.org BlogPosting structured data embedded
+// in post pages for rich search results.
+type BlogPostingJSONLD struct {
+	Context          string       `json:"@context"`
+	Type             string       `json:"@type"`
+	Headline         string       `json:"headline"`
+	Description      string       `json:"description,omitempty"`
+	DatePublished    string       `json:"datePublished"`
+	DateModified     string       `json:"dateModified"`
+	Author           jsonLDPerson `json:"author"`
+	Publisher        jsonLDPerson `json:"publisher"`
+	Keywords         []string     `json:"keywords,omitempty"`
+	URL              string       `json:"url"`
+	MainEntityOfPage string       `json:"mainEntityOfPage"`
+}
+
+// postJSONLD returns the BlogPosting JSON-LD for post, ready to be placed
+// inside a <script type="application/ld+json"> element. json.Marshal escapes
+// <, > and &, so post content cannot close the script early.
+func (b *Blog) postJSONLD(post *Post) template.JS {
+	url := b.postURL(post.Slug)
+	owner := jsonLDPerson{Type: "Person", Name: b.Config.BlogName}
+	data, err := json.Marshal(BlogPostingJSONLD{
+		Context:          "https://schema.org",
+		Type:             "BlogPosting",
+		Headline:         post.Title,
+		Description:      post.Excerpt,
+		DatePublished:    post.Date.Format(time.RFC3339),
+		DateModified:     post.Updated.Format(time.RFC3339),
+		Author:           owner,
+		Publisher:        owner,
+		Keywords:         post.Tags,
+		URL:              url,
+		MainEntityOfPage: url,
+	})
+	if err != nil {
+		return ""
+	}
+	return template.JS(data)
+}
@@ -0,0 +1,378 @@
+package blog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PostJSON is the structured representation of a post served by the JSON API.
+type PostJSON struct {
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	Date        string   `json:"date"`
+	Updated     string   `json:"updated"`
+	Tags        []string `json:"tags"`
+	Slug        string   `json:"slug"`
+	HTMLContent string   `json:"htmlContent"`
+}
+
+// PostListJSON is a page of post metadata served by the JSON API.
+type PostListJSON struct {
+	Total int               `json:"total"`
+	Page  int               `json:"page"`
+	Limit int               `json:"limit"`
+	Posts []SearchIndexPost `json:"posts"`
+}
+
+// SearchResultJSON is a single search hit served by the JSON API.
+type SearchResultJSON struct {
+	SearchIndexPost
+	Snippet   string `json:"snippet,omitempty"`
+	Broadened bool   `json:"broadened,omitempty"` // found by the substring fallback
+}
+
+const maxPageLimit = 50
+
+// Router returns the HTTP handler for the live server. Pages are rendered
+// on each request from the same data the static export uses, and every
+// request is logged. Responses carry security headers including
+// Config.CSP. With Config.CanonicalHost set, other hosts are redirected
+// to it. The search and suggestions APIs are rate limited per client.
+// Routes answer GET and HEAD, except /api/theme which takes POST; other
+// methods get a 405 listing the allowed ones in its Allow header.
+// Scheduled posts are published once their date passes.
+func (b *Blog) Router() http.Handler {
+	limiter := newRateLimiter(b.Config.RateLimit, b.Config.RateBurst, b.Config.TrustProxy)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", b.handleHome)
+	mux.HandleFunc("GET /post/", b.handlePost)
+	mux.HandleFunc("GET /search", b.handleSearch)
+	mux.HandleFunc("GET /search/", b.handleSearch)
+	mux.HandleFunc("GET /archive", b.handleArchive)
+	mux.HandleFunc("GET /archive/", b.handleArchive)
+	mux.HandleFunc("GET /category", b.handleCategory)
+	mux.HandleFunc("GET /category/", b.handleCategory)
+	mux.HandleFunc("GET /search-index.json", b.handleSearchIndex)
+	mux.HandleFunc("GET /robots.txt", b.handleRobots)
+	mux.HandleFunc("GET /sitemap.xml", b.handleSitemap)
+	mux.HandleFunc("GET /rss.xml", b.handleRSS)
+	mux.HandleFunc("GET /atom.xml", b.handleAtom)
+	mux.HandleFunc("GET /feed.json", b.handleJSONFeed)
+	mux.HandleFunc("GET /manifest.webmanifest", b.handleWebManifest)
+	mux.HandleFunc("GET /sw.js", b.handleServiceWorker)
+	mux.HandleFunc("GET /version", b.handleVersion)
+	mux.HandleFunc("GET "+healthPath, b.handleHealth)
+	mux.HandleFunc("GET /api/post/", b.handlePostJSON)
+	mux.HandleFunc("GET /api/posts", b.handlePostsJSON)
+	mux.HandleFunc("GET /api/slugs", b.handleSlugsJSON)
+	mux.HandleFunc("GET /api/tags", b.handleTagsJSON)
+	mux.HandleFunc("GET /api/search", limiter.limit(b.handleSearchJSON))
+	mux.HandleFunc("GET /api/suggestions", limiter.limit(b.handleSuggestions))
+	mux.HandleFunc("POST /api/theme", b.handleTheme)
+	mux.HandleFunc("GET /api/stats", b.handleStats)
+	mux.HandleFunc("GET /api/search-stats", b.handleSearchStats)
+
+	if staticFiles, err := fs.Sub(b.staticFS, "static"); err == nil {
+		mux.HandleFunc("GET /static/og/", b.handleOGImage)
+		mux.Handle("GET /static/", http.StripPrefix("/static/", withContentTypes(withETags(b.staticETags, http.FileServer(http.FS(staticFiles))))))
+	}
+	return logRequests(canonicalHost(b.Config.CanonicalHost, b.Config.TrustProxy, securityHeaders(b.Config.CSP, withoutHeadBodies(b.publishScheduled(mux)))))
+}
+
+// publishScheduled publishes scheduled posts that have come due before
+// serving a request. Requests hold b.publishing for reading, so posts are
+// only published between them and handlers never see a half-updated list.
+func (b *Blog) publishScheduled(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b.publishing.RLock()
+		due := b.due()
+		b.publishing.RUnlock()
+		if due {
+			b.publishing.Lock()
+			if b.due() { // another request may have published them
+				b.publishDue()
+			}
+			b.publishing.Unlock()
+		}
+
+		b.publishing.RLock()
+		defer b.publishing.RUnlock()
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (b *Blog) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (b *Blog) handleHome(w http.ResponseWriter, r *http.Request) {
+	data := b.homeData()
+	data["Theme"] = b.themeFor(r)
+	data["Nonce"] = cspNonce(r)
+	b.render(w, "index.html", data)
+}
+
+func (b *Blog) handlePost(w http.ResponseWriter, r *http.Request) {
+	slug := strings.Trim(strings.TrimPrefix(r.URL.Path, "/post/"), "/")
+
+	amp := false
+	if b.Config.AMP && strings.HasSuffix(slug, "/amp") {
+		slug = strings.TrimSuffix(slug, "/amp")
+		amp = true
+	}
+
+	post, ok := b.posts[slug]
+	preview := false
+	if !ok {
+		post, ok = b.drafts[slug]
+		ok = ok && b.validPreview(r, slug)
+		preview = ok
+	}
+	if !ok {
+		if !b.redirect(w, r, slug) {
+			b.notFound(w, r, slug)
+		}
+		return
+	}
+
+	// Only the trailing-slash form is canonical; it is also the form the
+	// static export writes as post/{slug}/index.html.
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		target := r.URL.Path + "/"
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+		return
+	}
+
+	// HEAD requests come from link checkers and crawlers, not readers.
+	if preview {
+		w.Header().Set("X-Robots-Tag", "noindex")
+	} else if r.Method != http.MethodHead {
+		b.views.increment(post.Slug)
+	}
+
+	if amp {
+		b.render(w, "amp.html", b.ampData(post))
+		return
+	}
+	data := b.postData(post)
+	data["Theme"] = b.themeFor(r)
+	data["Nonce"] = cspNonce(r)
+	b.render(w, "post.html", data)
+}
+
+func (b *Blog) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query, page := r.URL.Query().Get("q"), queryInt(r, "page", 1)
+	data := b.searchData(query, page)
+	// Later pages repeat a search already counted.
+	if page == 1 {
+		b.recordSearch(query, data["TotalResults"].(int), data["Broadened"].(bool), data["CacheHit"].(bool))
+	}
+	data["Theme"] = b.themeFor(r)
+	data["Nonce"] = cspNonce(r)
+	b.render(w, "search.html", data)
+}
+
+func (b *Blog) handleArchive(w http.ResponseWriter, r *http.Request) {
+	data := b.archiveData()
+	data["Theme"] = b.themeFor(r)
+	data["Nonce"] = cspNonce(r)
+	b.render(w, "archive.html", data)
+}
+
+func (b *Blog) handleRobots(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(b.robotsTxt())
+}
+
+func (b *Blog) handleSitemap(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Write(b.sitemapXML())
+}
+
+func (b *Blog) handleRSS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	w.Write(b.rssXML())
+}
+
+func (b *Blog) handleAtom(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.Write(b.atomXML())
+}
+
+func (b *Blog) handleJSONFeed(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/feed+json; charset=utf-8")
+	writeJSON(w, http.StatusOK, b.jsonFeed())
+}
+
+// handleSearchIndex serves the cached search index, answering conditional
+// requests with 304 Not Modified until the index changes.
+func (b *Blog) handleSearchIndex(w http.ResponseWriter, r *http.Request) {
+	data, etag, modTime := b.searchIndexJSON()
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag)
+	http.ServeContent(w, r, "", modTime, bytes.NewReader(data))
+}
+
+func (b *Blog) handlePostJSON(w http.ResponseWriter, r *http.Request) {
+	slug := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/post/"), "/")
+	post, ok := b.posts[slug]
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "post not found")
+		return
+	}
+
+	tags := post.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	writeJSON(w, http.StatusOK, PostJSON{
+		ID:          post.ID,
+		Title:       post.Title,
+		Date:        post.Date.Format(time.RFC3339),
+		Updated:     post.Updated.Format(time.RFC3339),
+		Tags:        tags,
+		Slug:        post.Slug,
+		HTMLContent: string(post.HTMLContent),
+	})
+}
+
+func (b *Blog) handlePostsJSON(w http.ResponseWriter, r *http.Request) {
+	page := queryInt(r, "page", 1)
+	limit := queryInt(r, "limit", b.Config.PostsPerPage)
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	// Pages past the end are empty. The page is compared before it is
+	// multiplied, since (page-1)*limit overflows for a huge ?page.
+	posts := []SearchIndexPost{}
+	if pages := (len(b.postList) + limit - 1) / limit; page-1 < pages {
+		start := (page - 1) * limit
+		for _, post := range b.postList[start:min(start+limit, len(b.postList))] {
+			posts = append(posts, newSearchIndexPost(post))
+		}
+	}
+
+	writeJSON(w, http.StatusOK, PostListJSON{
+		Total: len(b.postList),
+		Page:  page,
+		Limit: limit,
+		Posts: posts,
+	})
+}
+
+func (b *Blog) handleSlugsJSON(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, b.slugList())
+}
+
+func (b *Blog) handleTagsJSON(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, b.tagList())
+}
+
+func (b *Blog) handleSearchJSON(w http.ResponseWriter, r *http.Request) {
+	query, ok := apiQuery(w, r)
+	if !ok {
+		return
+	}
+	posts, broadened, cached := b.cachedSearch(query)
+	b.recordSearch(query, len(posts), broadened, cached)
+	results := []SearchResultJSON{}
+	for _, post := range posts {
+		results = append(results, SearchResultJSON{
+			SearchIndexPost: newSearchIndexPost(post),
+			Snippet:         snippet(post.Content, tokenize(query), snippetLength),
+			Broadened:       broadened,
+		})
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+// maxQueryLength bounds the q parameter of the search and suggestion APIs,
+// in bytes. No real query comes close; longer ones are rejected rather than
+// silently cut.
+const maxQueryLength = 256
+
+// apiQuery returns the q parameter of an API request, answering 400 and
+// reporting false when it is too long.
+func apiQuery(w http.ResponseWriter, r *http.Request) (string, bool) {
+	query := r.URL.Query().Get("q")
+	if len(query) > maxQueryLength {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("q must be at most %d bytes", maxQueryLength))
+		return "", false
+	}
+	return query, true
+}
+
+// queryInt reads a positive integer query parameter, falling back to def
+// when it is missing or invalid.
+func queryInt(r *http.Request, name string, def int) int {
+	n, err := strconv.Atoi(r.URL.Query().Get(name))
+	if err != nil || n < 1 {
+		return def
+	}
+	return n
+}
+
+// render executes a page template into a buffer first so a failing
+// template produces a 500 instead of a half-written page.
+func (b *Blog) render(w http.ResponseWriter, name string, data interface{}) {
+	b.renderStatus(w, http.StatusOK, name, data)
+}
+
+// renderStatus is render with a status code other than 200 OK.
+func (b *Blog) renderStatus(w http.ResponseWriter, status int, name string, data interface{}) {
+	if b.templates == nil {
+		http.Error(w, "templates not loaded", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := b.templates.ExecuteTemplate(&buf, name, data); err != nil {
+		slog.Error("Error rendering template", "template", name, "err", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+// writeJSON encodes v as the response body. Handlers may set a more
+// specific JSON Content-Type beforehand.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Error encoding JSON response", "err", err)
+	}
+}
+
+// APIError is the body of every failed /api/ response, so clients can tell
+// a failure from an empty result.
+type APIError struct {
+	Error APIErrorDetail `json:"error"`
+}
+
+// APIErrorDetail describes what went wrong. Code repeats the HTTP status.
+type APIErrorDetail struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// writeJSONError answers with status and an APIError carrying msg.
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, APIError{Error: APIErrorDetail{Code: status, Message: msg}})
+}
@@ -0,0 +1,476 @@
+package blog
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHandlePostJSON(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\ntags: go, web\n---\n# Hello World",
+	})
+	router := blog.Router()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/post/hello", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected Content-Type application/json, got '%s'", ct)
+	}
+
+	var post PostJSON
+	if err := json.Unmarshal(rec.Body.Bytes(), &post); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if post.Slug != "hello" || post.Title != "Hello" {
+		t.Errorf("Unexpected post %+v", post)
+	}
+	if post.Date != "2024-01-27T00:00:00Z" {
+		t.Errorf("Expected RFC3339 date, got '%s'", post.Date)
+	}
+	if len(post.Tags) != 2 || post.HTMLContent == "" {
+		t.Errorf("Expected tags and HTML content, got %+v", post)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/post/missing", nil))
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected Content-Type application/json, got '%s'", ct)
+	}
+}
+
+func TestHandlePostsJSONPagination(t *testing.T) {
+	files := make(map[string]string)
+	for i := 1; i <= 25; i++ {
+		files[fmt.Sprintf("post-%02d.md", i)] = fmt.Sprintf("---\ntitle: Post %d\ndate: 2024-01-%02d\n---\nBody", i, i)
+	}
+	router := newTestBlog(t, files).Router()
+
+	get := func(url string) PostListJSON {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %s, got %d", url, rec.Code)
+		}
+		var list PostListJSON
+		if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return list
+	}
+
+	first := get("/api/posts")
+	if first.Total != 25 || first.Page != 1 || first.Limit != 10 || len(first.Posts) != 10 {
+		t.Errorf("Unexpected first page: total=%d page=%d limit=%d posts=%d", first.Total, first.Page, first.Limit, len(first.Posts))
+	}
+	if first.Posts[0].Slug != "post-25" {
+		t.Errorf("Expected newest post first, got '%s'", first.Posts[0].Slug)
+	}
+
+	middle := get("/api/posts?page=2&limit=5")
+	if middle.Page != 2 || len(middle.Posts) != 5 || middle.Posts[0].Slug != "post-20" {
+		t.Errorf("Unexpected middle page: page=%d posts=%v", middle.Page, middle.Posts)
+	}
+
+	clamped := get("/api/posts?limit=1000")
+	if clamped.Limit != maxPageLimit || len(clamped.Posts) != 25 {
+		t.Errorf("Expected limit clamped to %d, got limit=%d posts=%d", maxPageLimit, clamped.Limit, len(clamped.Posts))
+	}
+
+	for _, url := range []string{
+		"/api/posts?page=6&limit=5",
+		"/api/posts?page=4",
+		"/api/posts?page=9223372036854775807&limit=50",
+		"/api/posts?page=4611686018427387904&limit=4",
+	} {
+		if list := get(url); len(list.Posts) != 0 {
+			t.Errorf("Expected an empty page past the end for %s, got %d posts", url, len(list.Posts))
+		}
+	}
+	if last := get("/api/posts?page=5&limit=5"); len(last.Posts) != 5 || last.Posts[4].Slug != "post-01" {
+		t.Errorf("Expected the last full page to end with the oldest post, got %v", last.Posts)
+	}
+}
+
+func TestHandleSlugsJSON(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"older.md": "---\ntitle: Older\ndate: 2024-01-01\n---\nBody",
+		"newer.md": "---\ntitle: Newer\ndate: 2024-02-01\n---\nBody",
+		"draft.md": "---\ntitle: Draft\ndate: 2024-03-01\ndraft: true\n---\nBody",
+	})
+	blog.Config.BaseURL = "https://blog.example.com"
+
+	rec := httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/slugs", nil))
+	var slugs []SlugJSON
+	if err := json.Unmarshal(rec.Body.Bytes(), &slugs); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(slugs) != len(blog.postList) {
+		t.Fatalf("Expected one entry per post, got %v", slugs)
+	}
+	want := SlugJSON{Slug: "newer", URL: "https://blog.example.com/post/newer/", Title: "Newer", Date: "2024-02-01"}
+	if slugs[0] != want || slugs[1].Slug != "older" {
+		t.Errorf("Expected absolute URLs newest first, got %v", slugs)
+	}
+
+	var exported []SlugJSON
+	if err := json.Unmarshal(blog.ExportFiles()["slugs.json"], &exported); err != nil || !reflect.DeepEqual(exported, slugs) {
+		t.Errorf("Expected slugs.json to match the API, got %v, %v", exported, err)
+	}
+}
+
+func TestHandleTagsJSON(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"one.md":   "---\ntitle: One\ndate: 2024-01-01\ntags: go, web\n---\nBody",
+		"two.md":   "---\ntitle: Two\ndate: 2024-01-02\ntags: Go, café\n---\nBody",
+		"three.md": "---\ntitle: Three\ndate: 2024-01-03\ntags: go, GO, cafe, api\n---\nBody",
+		"four.md":  "---\ntitle: Four\ndate: 2024-01-04\n---\nBody",
+		"draft.md": "---\ntitle: Draft\ndate: 2024-01-05\ntags: web, zeta\ndraft: true\n---\nBody",
+	})
+
+	want := []TagJSON{{"go", 3}, {"cafe", 2}, {"api", 1}, {"web", 1}}
+	for i := 0; i < 5; i++ {
+		rec := httptest.NewRecorder()
+		blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/tags", nil))
+		var tags []TagJSON
+		if err := json.Unmarshal(rec.Body.Bytes(), &tags); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if !reflect.DeepEqual(tags, want) {
+			t.Fatalf("Expected %v, got %v", want, tags)
+		}
+	}
+
+	var exported []TagJSON
+	if err := json.Unmarshal(blog.ExportFiles()["tags.json"], &exported); err != nil || !reflect.DeepEqual(exported, want) {
+		t.Errorf("Expected tags.json to match the API, got %v, %v", exported, err)
+	}
+
+	empty := newTestBlog(t, map[string]string{"untagged.md": "---\ntitle: Untagged\ndate: 2024-01-01\n---\nBody"})
+	rec := httptest.NewRecorder()
+	empty.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/tags", nil))
+	if body := strings.TrimSpace(rec.Body.String()); body != "[]" {
+		t.Errorf("Expected an empty array without tags, got %s", body)
+	}
+}
+
+func TestHandlePostOGType(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"talk.md": "---\ntitle: My Talk\ndate: 2024-01-27\nog_type: video\n---\nRecording.",
+	})
+
+	rec := httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/post/talk/", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `<meta property="og:type" content="video">`) {
+		t.Errorf("Expected og:type video in rendered page")
+	}
+}
+
+func TestHandlePostJSONLD(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello </script> World\ndate: 2024-01-27\nupdated: 2024-02-01\ntags: go, web\n---\nBody.",
+	})
+	blog.Config.BaseURL = "https://cenkcorapci.com"
+
+	rec := httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/post/hello/", nil))
+
+	body := rec.Body.String()
+	const open = `<script type="application/ld+json">`
+	start := strings.Index(body, open)
+	if start < 0 {
+		t.Fatalf("Expected a JSON-LD block in rendered page")
+	}
+	block := body[start+len(open):]
+	block = block[:strings.Index(block, "</script>")]
+
+	var ld BlogPostingJSONLD
+	if err := json.Unmarshal([]byte(block), &ld); err != nil {
+		t.Fatalf("Failed to parse JSON-LD %q: %v", block, err)
+	}
+	if ld.Type != "BlogPosting" || ld.Headline != "Hello </script> World" {
+		t.Errorf("Expected BlogPosting headline 'Hello </script> World', got %s %q", ld.Type, ld.Headline)
+	}
+	if ld.DatePublished != "2024-01-27T00:00:00Z" || ld.DateModified != "2024-02-01T00:00:00Z" {
+		t.Errorf("Unexpected dates: published %s, modified %s", ld.DatePublished, ld.DateModified)
+	}
+	if ld.URL != "https://cenkcorapci.com/post/hello/" || ld.Publisher.Name != blog.Config.BlogName {
+		t.Errorf("Unexpected url %s or publisher %q", ld.URL, ld.Publisher.Name)
+	}
+	if len(ld.Keywords) != 2 || ld.Keywords[0] != "go" {
+		t.Errorf("Expected keywords [go web], got %v", ld.Keywords)
+	}
+}
+
+func TestHandleSearchJSON(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"old.md":   "---\ntitle: Old Go Post\ndate: 2023-05-01\ntags: go\n---\nWriting servers in golang.",
+		"new.md":   "---\ntitle: New Go Post\ndate: 2024-05-01\ntags: go, web\n---\nMore golang servers.",
+		"other.md": "---\ntitle: Cooking\ndate: 2024-06-01\n---\nPasta.",
+	})
+	router := blog.Router()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/search?q=servers", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	var results []map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	for _, key := range []string{"id", "title", "slug", "date", "tags", "snippet"} {
+		if _, ok := results[0][key]; !ok {
+			t.Errorf("Expected result to have field '%s', got %v", key, results[0])
+		}
+	}
+	if results[0]["slug"] != "new" || results[1]["slug"] != "old" {
+		t.Errorf("Expected newest result first, got %v then %v", results[0]["slug"], results[1]["slug"])
+	}
+	if snippet, _ := results[0]["snippet"].(string); !strings.Contains(snippet, "servers") {
+		t.Errorf("Expected snippet to contain the query term, got '%s'", snippet)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/search?q=", nil))
+	if body := strings.TrimSpace(rec.Body.String()); body != "[]" {
+		t.Errorf("Expected empty array for blank query, got %s", body)
+	}
+}
+
+func TestAPIErrors(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHello servers.",
+	})
+	blog.Config.RateBurst = 3 // the last request below is over the limit
+	router := blog.Router()
+
+	long := strings.Repeat("a", maxQueryLength+1)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/suggestions?q="+long[:maxQueryLength], nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected a query of exactly %d bytes to be accepted, got %d", maxQueryLength, rec.Code)
+	}
+
+	tests := []struct {
+		method, path string
+		code         int
+	}{
+		{http.MethodGet, "/api/search?q=" + long, http.StatusBadRequest},
+		{http.MethodGet, "/api/suggestions?q=" + long, http.StatusBadRequest},
+		{http.MethodGet, "/api/post/missing", http.StatusNotFound},
+		{http.MethodPost, "/api/theme?theme=pink", http.StatusBadRequest},
+		{http.MethodGet, "/api/search?q=servers", http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+		var body APIError
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Errorf("%s %.40s: expected an error envelope, got %q", tt.method, tt.path, rec.Body.String())
+			continue
+		}
+		if rec.Code != tt.code || body.Error.Code != tt.code || body.Error.Message == "" {
+			t.Errorf("%s %.40s: expected status and code %d with a message, got %d and %+v", tt.method, tt.path, tt.code, rec.Code, body)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%s %.40s: expected Content-Type application/json, got '%s'", tt.method, tt.path, ct)
+		}
+	}
+}
+
+func TestHandlePostCanonicalRedirect(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nBody",
+	})
+	blog.Config.BaseURL = "https://cenkcorapci.com"
+	router := blog.Router()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/post/hello?ref=feed", nil))
+	if rec.Code != http.StatusMovedPermanently {
+		t.Fatalf("Expected status 301, got %d", rec.Code)
+	}
+	if loc := rec.Header().Get("Location"); loc != "/post/hello/?ref=feed" {
+		t.Errorf("Expected Location '/post/hello/?ref=feed', got '%s'", loc)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/post/hello/", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for the canonical URL, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `<link rel="canonical" href="https://cenkcorapci.com/post/hello/">`) {
+		t.Errorf("Expected canonical link in the rendered post")
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/post/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown post, got %d", rec.Code)
+	}
+}
+
+func TestHandlePostsJSONUsesPostsPerPage(t *testing.T) {
+	files := make(map[string]string)
+	for i := 1; i <= 8; i++ {
+		files[fmt.Sprintf("post-%d.md", i)] = fmt.Sprintf("---\ntitle: Post %d\ndate: 2024-01-%02d\n---\nBody", i, i)
+	}
+	blog := newTestBlog(t, files)
+	blog.Config.PostsPerPage = 5
+
+	rec := httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/posts", nil))
+
+	var list PostListJSON
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if list.Limit != 5 || len(list.Posts) != 5 {
+		t.Errorf("Expected a default limit of 5 from config, got limit=%d posts=%d", list.Limit, len(list.Posts))
+	}
+}
+
+func TestHandleRobots(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHello.",
+	})
+	blog.Config.BaseURL = "https://example.com"
+
+	rec := httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "User-agent: *\nAllow: /\n") {
+		t.Errorf("Expected robots.txt to allow all user agents, got %q", body)
+	}
+	if !strings.Contains(body, "Sitemap: https://example.com/sitemap.xml") {
+		t.Errorf("Expected sitemap line, got %q", body)
+	}
+
+	blog.Config.BaseURL = ""
+	if strings.Contains(string(blog.robotsTxt()), "Sitemap:") {
+		t.Errorf("Expected no sitemap line without a base URL")
+	}
+}
+
+func TestHandleRobotsWithoutBaseURL(t *testing.T) {
+	root := os.DirFS("../..")
+	blog, err := NewBlogWithConfig(Config{}, root, root, embed.FS{})
+	if err != nil {
+		t.Fatalf("Failed to create blog: %v", err)
+	}
+	if blog.Config.BaseURL != "" {
+		t.Errorf("Expected an unset base URL to stay empty, got %q", blog.Config.BaseURL)
+	}
+
+	rec := httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+	if body := rec.Body.String(); body != "User-agent: *\nAllow: /\n" {
+		t.Errorf("Expected robots.txt without a sitemap line, got %q", body)
+	}
+}
+
+func TestSearchIndexConditionalGet(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHi.",
+	})
+	router := blog.Router()
+
+	get := func(etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/search-index.json", nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first, second := get(""), get("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("Expected 200 with an ETag, got %d and %q", first.Code, etag)
+	}
+	if second.Header().Get("ETag") != etag {
+		t.Errorf("Expected the same ETag without changes, got %q and %q", etag, second.Header().Get("ETag"))
+	}
+	if first.Header().Get("Last-Modified") == "" {
+		t.Errorf("Expected a Last-Modified header")
+	}
+	var index SearchIndex
+	if err := json.Unmarshal(first.Body.Bytes(), &index); err != nil || len(index.Posts) != 1 {
+		t.Errorf("Expected a search index with one post, got %v (%v)", index.Posts, err)
+	}
+
+	if rec := get(etag); rec.Code != http.StatusNotModified {
+		t.Errorf("Expected 304 for a matching If-None-Match, got %d", rec.Code)
+	}
+
+	blog.AddMarkdown("new.md", "---\ntitle: New\ndate: 2024-02-01\n---\nFresh.")
+	if rec := get(etag); rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Errorf("Expected a new ETag after adding a post, got %d and %q", rec.Code, rec.Header().Get("ETag"))
+	}
+}
+
+func TestRouterMethods(t *testing.T) {
+	router := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHi.",
+	}).Router()
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+
+	get := serve(http.MethodGet, "/post/hello/")
+	head := serve(http.MethodHead, "/post/hello/")
+	if head.Code != http.StatusOK || head.Body.Len() != 0 {
+		t.Errorf("Expected HEAD to return 200 without a body, got %d with %d bytes", head.Code, head.Body.Len())
+	}
+	if head.Header().Get("Content-Length") != strconv.Itoa(get.Body.Len()) || head.Header().Get("Content-Type") != get.Header().Get("Content-Type") {
+		t.Errorf("Expected HEAD to carry GET's headers, got %v", head.Header())
+	}
+	if rec := serve(http.MethodHead, "/post/missing/"); rec.Code != http.StatusNotFound || rec.Body.Len() != 0 {
+		t.Errorf("Expected HEAD of a missing post to return 404 without a body, got %d", rec.Code)
+	}
+
+	for _, path := range []string{"/", "/post/hello/", "/api/posts"} {
+		rec := serve(http.MethodPost, path)
+		if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "GET, HEAD" {
+			t.Errorf("Expected POST %s to return 405 allowing GET and HEAD, got %d with Allow %q", path, rec.Code, rec.Header().Get("Allow"))
+		}
+	}
+	if rec := serve(http.MethodGet, "/api/theme"); rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "POST" {
+		t.Errorf("Expected GET /api/theme to return 405 allowing POST, got %d with Allow %q", rec.Code, rec.Header().Get("Allow"))
+	}
+}
@@ -0,0 +1,94 @@
+package blog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// viewCounter keeps anonymous per-post view counts in memory. It records
+// nothing about readers, only how often each slug was served.
+type viewCounter struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func newViewCounter() *viewCounter {
+	return &viewCounter{counts: make(map[string]int64)}
+}
+
+func (c *viewCounter) increment(slug string) {
+	c.mu.Lock()
+	c.counts[slug]++
+	c.mu.Unlock()
+}
+
+func (c *viewCounter) snapshot() map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counts := make(map[string]int64, len(c.counts))
+	for slug, n := range c.counts {
+		counts[slug] = n
+	}
+	return counts
+}
+
+// LoadStats restores view counts saved by SaveStats. A missing file is not
+// an error, so the first start needs no setup.
+func (b *Blog) LoadStats(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read stats: %w", err)
+	}
+
+	var counts map[string]int64
+	if err := json.Unmarshal(data, &counts); err != nil {
+		return fmt.Errorf("failed to parse stats %s: %w", path, err)
+	}
+
+	b.views.mu.Lock()
+	defer b.views.mu.Unlock()
+	for slug, n := range counts {
+		b.views.counts[slug] += n
+	}
+	return nil
+}
+
+// SaveStats writes the view counts to path as JSON. The file is replaced
+// atomically so a crash mid-write cannot lose earlier counts.
+func (b *Blog) SaveStats(path string) error {
+	data, err := json.Marshal(b.views.snapshot())
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".stats-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to save stats: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save stats: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save stats: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to save stats: %w", err)
+	}
+	return nil
+}
+
+func (b *Blog) handleStats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, b.views.snapshot())
+}
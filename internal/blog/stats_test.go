@@ -0,0 +1,80 @@
+package blog
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestViewCounts(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHello.",
+	})
+	router := blog.Router()
+
+	for i := 0; i < 2; i++ {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/post/hello/", nil))
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/post/missing/", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodHead, "/post/hello/", nil))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/stats", nil))
+	var counts map[string]int64
+	if err := json.Unmarshal(rec.Body.Bytes(), &counts); err != nil {
+		t.Fatalf("Failed to decode stats: %v", err)
+	}
+	if len(counts) != 1 || counts["hello"] != 2 {
+		t.Errorf("Expected 2 views of 'hello' only, got %v", counts)
+	}
+}
+
+func TestViewCounterConcurrent(t *testing.T) {
+	counter := newViewCounter()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				counter.increment("hello")
+				counter.snapshot()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := counter.snapshot()["hello"]; n != 5000 {
+		t.Errorf("Expected 5000 views, got %d", n)
+	}
+}
+
+func TestStatsPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.json")
+	files := map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHello.",
+	}
+
+	blog := newTestBlog(t, files)
+	if err := blog.LoadStats(path); err != nil {
+		t.Fatalf("Expected a missing stats file to be ignored, got %v", err)
+	}
+	blog.views.increment("hello")
+	blog.views.increment("hello")
+	if err := blog.SaveStats(path); err != nil {
+		t.Fatalf("Failed to save stats: %v", err)
+	}
+
+	restarted := newTestBlog(t, files)
+	if err := restarted.LoadStats(path); err != nil {
+		t.Fatalf("Failed to load stats: %v", err)
+	}
+	restarted.views.increment("hello")
+	if n := restarted.views.snapshot()["hello"]; n != 3 {
+		t.Errorf("Expected counts to survive a restart, got %d", n)
+	}
+}
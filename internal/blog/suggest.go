@@ -0,0 +1,178 @@
+package blog
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// maxSuggestions is how many completions /api/suggestions returns.
+	maxSuggestions = 10
+	// minSuggestionQueryLength matches the shortest input static/search.js
+	// completes.
+	minSuggestionQueryLength = 2
+)
+
+// suggestionTerm is a completion stored under the key prefixes are
+// compared against. Among the terms matching a prefix, lower ranks are
+// suggested first.
+type suggestionTerm struct {
+	key  string
+	text string
+	rank int
+}
+
+// suggestionIndex holds the completions for search input in lists sorted
+// by key, so a prefix is answered with a binary search rather than a scan
+// of every post. Like getSuggestions in static/search.js it suggests tags,
+// most used first, then titles in listing order, then indexed words, most
+// frequent first.
+type suggestionIndex struct {
+	tags   []suggestionTerm // keyed by normalizeTag
+	titles []suggestionTerm // keyed by each folded title from every word on
+	words  []suggestionTerm // keyed by the indexed word
+}
+
+// newSuggestionIndex builds the completions for posts, in listing order,
+// and the words of index.
+func newSuggestionIndex(posts []*Post, index map[string][]string) *suggestionIndex {
+	tagCounts := make(map[string]int)
+	for _, post := range posts {
+		for _, tag := range post.Tags {
+			tagCounts[tag]++
+		}
+	}
+	tags := make([]string, 0, len(tagCounts))
+	for tag := range tagCounts {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tagCounts[tags[i]] != tagCounts[tags[j]] {
+			return tagCounts[tags[i]] > tagCounts[tags[j]]
+		}
+		return tags[i] < tags[j]
+	})
+
+	words := make([]string, 0, len(index))
+	for word := range index {
+		words = append(words, word)
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if len(index[words[i]]) != len(index[words[j]]) {
+			return len(index[words[i]]) > len(index[words[j]])
+		}
+		return words[i] < words[j]
+	})
+
+	idx := &suggestionIndex{}
+	for rank, tag := range tags {
+		idx.tags = append(idx.tags, suggestionTerm{key: normalizeTag(tag), text: tag, rank: rank})
+	}
+	for rank, post := range posts {
+		for _, key := range wordSuffixes(foldCase(post.Title)) {
+			idx.titles = append(idx.titles, suggestionTerm{key: key, text: post.Title, rank: rank})
+		}
+	}
+	for rank, word := range words {
+		idx.words = append(idx.words, suggestionTerm{key: word, text: word, rank: rank})
+	}
+	for _, terms := range [][]suggestionTerm{idx.tags, idx.titles, idx.words} {
+		sort.Slice(terms, func(i, j int) bool {
+			if terms[i].key != terms[j].key {
+				return terms[i].key < terms[j].key
+			}
+			return terms[i].rank < terms[j].rank
+		})
+	}
+	return idx
+}
+
+// wordSuffixes returns s from the start of each of its words on, so a
+// prefix search over them finds the words and phrases s contains.
+func wordSuffixes(s string) []string {
+	var suffixes []string
+	inWord := false
+	for i, r := range s {
+		isWordRune := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWordRune && !inWord {
+			suffixes = append(suffixes, s[i:])
+		}
+		inWord = isWordRune || (inWord && unicode.IsMark(r))
+	}
+	return suffixes
+}
+
+// suggest returns up to n distinct completions for query, compared
+// case-insensitively.
+func (idx *suggestionIndex) suggest(query string, n int) []string {
+	query = strings.TrimSpace(query)
+	suggestions := []string{}
+	if utf8.RuneCountInString(query) < minSuggestionQueryLength {
+		return suggestions
+	}
+
+	seen := make(map[string]bool)
+	groups := []struct {
+		terms  []suggestionTerm
+		prefix string
+	}{
+		{idx.tags, normalizeTag(query)},
+		{idx.titles, foldCase(query)},
+		{idx.words, foldCase(query)},
+	}
+	for _, group := range groups {
+		for _, term := range matchingTerms(group.terms, group.prefix) {
+			if key := foldCase(term.text); !seen[key] {
+				seen[key] = true
+				suggestions = append(suggestions, term.text)
+				if len(suggestions) == n {
+					return suggestions
+				}
+			}
+		}
+	}
+	return suggestions
+}
+
+// matchingTerms returns the terms whose keys start with prefix, by rank.
+func matchingTerms(terms []suggestionTerm, prefix string) []suggestionTerm {
+	start := sort.Search(len(terms), func(i int) bool {
+		return terms[i].key >= prefix
+	})
+	end := start
+	for end < len(terms) && strings.HasPrefix(terms[end].key, prefix) {
+		end++
+	}
+
+	matches := append([]suggestionTerm(nil), terms[start:end]...)
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].rank < matches[j].rank
+	})
+	return matches
+}
+
+// suggestions returns the suggestion index, building it if the cache was
+// invalidated since posts or the search index last changed.
+func (b *Blog) suggestions() *suggestionIndex {
+	c := &b.indexCache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.suggestions == nil {
+		b.invertedIndex.mu.RLock()
+		c.suggestions = newSuggestionIndex(b.postList, b.invertedIndex.index)
+		b.invertedIndex.mu.RUnlock()
+	}
+	return c.suggestions
+}
+
+func (b *Blog) handleSuggestions(w http.ResponseWriter, r *http.Request) {
+	query, ok := apiQuery(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, b.suggestions().suggest(query, maxSuggestions))
+}
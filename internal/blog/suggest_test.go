@@ -0,0 +1,128 @@
+package blog
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+// bruteForceSuggestions scans every post for query the way the suggestion
+// index is meant to answer it.
+func bruteForceSuggestions(b *Blog, query string, n int) []string {
+	query = strings.TrimSpace(query)
+	suggestions := []string{}
+	if len([]rune(query)) < minSuggestionQueryLength {
+		return suggestions
+	}
+	seen := make(map[string]bool)
+	add := func(text string) {
+		if key := foldCase(text); !seen[key] && len(suggestions) < n {
+			seen[key] = true
+			suggestions = append(suggestions, text)
+		}
+	}
+
+	tagCounts := make(map[string]int)
+	for _, post := range b.postList {
+		for _, tag := range post.Tags {
+			if strings.HasPrefix(normalizeTag(tag), normalizeTag(query)) {
+				tagCounts[tag]++
+			}
+		}
+	}
+	var tags []string
+	for tag := range tagCounts {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tagCounts[tags[i]] != tagCounts[tags[j]] {
+			return tagCounts[tags[i]] > tagCounts[tags[j]]
+		}
+		return tags[i] < tags[j]
+	})
+	for _, tag := range tags {
+		add(tag)
+	}
+
+	for _, post := range b.postList {
+		title := foldCase(post.Title)
+		prev := ' '
+		for i, r := range title {
+			wordStart := (unicode.IsLetter(r) || unicode.IsDigit(r)) && !unicode.IsLetter(prev) && !unicode.IsDigit(prev) && !unicode.IsMark(prev)
+			if wordStart && strings.HasPrefix(title[i:], foldCase(query)) {
+				add(post.Title)
+			}
+			prev = r
+		}
+	}
+
+	var words []string
+	for word := range b.invertedIndex.index {
+		if strings.HasPrefix(word, foldCase(query)) {
+			words = append(words, word)
+		}
+	}
+	sort.Slice(words, func(i, j int) bool {
+		ni, nj := len(b.invertedIndex.index[words[i]]), len(b.invertedIndex.index[words[j]])
+		if ni != nj {
+			return ni > nj
+		}
+		return words[i] < words[j]
+	})
+	for _, word := range words {
+		add(word)
+	}
+	return suggestions
+}
+
+func TestSuggestionsMatchBruteForce(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"go.md":      "---\ntitle: Learning Go\ndate: 2024-01-01\ntags: go, golang\n---\nGoroutines and gophers go together.",
+		"gofast.md":  "---\ntitle: Go Faster\ndate: 2024-02-01\ntags: go, performance\n---\nProfiling goroutines.",
+		"pasta.md":   "---\ntitle: Pasta Night\ndate: 2024-03-01\ntags: Cooking, Café\n---\nGood food and good company.",
+		"gardens.md": "---\ntitle: Garden Notes\ndate: 2024-04-01\ntags: gardening\n---\nGrowing garlic.",
+	})
+
+	for _, query := range []string{"go", "GO", "gar", "good", "cafe", "pasta n", "learning g", "per", "x", "zz", " go "} {
+		got := blog.suggestions().suggest(query, maxSuggestions)
+		if want := bruteForceSuggestions(blog, query, maxSuggestions); !reflect.DeepEqual(got, want) {
+			t.Errorf("suggest(%q) = %q, want %q", query, got, want)
+		}
+	}
+
+	got := blog.suggestions().suggest("go", maxSuggestions)
+	if len(got) < 3 || got[0] != "go" || got[1] != "golang" || got[2] != "Go Faster" {
+		t.Errorf("Expected tags before titles, got %q", got)
+	}
+	if got := blog.suggestions().suggest("go", 2); len(got) != 2 {
+		t.Errorf("Expected suggestions to be capped, got %q", got)
+	}
+}
+
+func TestSuggestionsFollowPostChanges(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"go.md": "---\ntitle: Learning Go\ndate: 2024-01-01\n---\nNotes.",
+	})
+	if got := blog.suggestions().suggest("rust", maxSuggestions); len(got) != 0 {
+		t.Fatalf("Expected no suggestions for rust yet, got %q", got)
+	}
+
+	if err := blog.AddMarkdown("rust.md", "---\ntitle: Rust Ownership\ndate: 2024-02-01\n---\nBorrowing."); err != nil {
+		t.Fatalf("Failed to add post: %v", err)
+	}
+	if got := blog.suggestions().suggest("rust", maxSuggestions); len(got) == 0 || got[0] != "Rust Ownership" {
+		t.Errorf("Expected the added post's title to be suggested, got %q", got)
+	}
+
+	rec := httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/suggestions?q=rus", nil))
+	var suggestions []string
+	if err := json.Unmarshal(rec.Body.Bytes(), &suggestions); err != nil || len(suggestions) == 0 || suggestions[0] != "Rust Ownership" {
+		t.Errorf("Expected suggestions from /api/suggestions, got %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,124 @@
+package blog
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// relatedTagsCount is how many co-occurring tags a tag's search page lists.
+const relatedTagsCount = 5
+
+// coOccurringTags returns up to n tags that appear alongside tag on the same
+// posts, most frequent first with ties broken alphabetically. tag itself is
+// matched and excluded through normalizeTag.
+func (b *Blog) coOccurringTags(tag string, n int) []string {
+	counts := make(map[string]int)
+	for _, post := range b.postList {
+		if !hasTag(post, tag) {
+			continue
+		}
+		for _, other := range post.Tags {
+			if normalizeTag(other) != normalizeTag(tag) {
+				counts[other]++
+			}
+		}
+	}
+
+	related := make([]string, 0, len(counts))
+	for other := range counts {
+		related = append(related, other)
+	}
+	sort.Slice(related, func(i, j int) bool {
+		if counts[related[i]] != counts[related[j]] {
+			return counts[related[i]] > counts[related[j]]
+		}
+		return related[i] < related[j]
+	})
+	if len(related) > n {
+		related = related[:n]
+	}
+	return related
+}
+
+// TagJSON is an entry of /api/tags and tags.json.
+type TagJSON struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// tagList counts the published posts carrying each tag, most used first
+// with ties broken alphabetically. Tags are grouped through normalizeTag,
+// like tag searches, and listed under their most common spelling.
+func (b *Blog) tagList() []TagJSON {
+	counts := make(map[string]int)               // normalized tag -> posts
+	spellings := make(map[string]map[string]int) // normalized tag -> spelling -> uses
+	for _, post := range b.posts {
+		seen := make(map[string]bool, len(post.Tags))
+		for _, tag := range post.Tags {
+			key := normalizeTag(tag)
+			if key == "" {
+				continue
+			}
+			if spellings[key] == nil {
+				spellings[key] = make(map[string]int)
+			}
+			spellings[key][tag]++
+			if !seen[key] {
+				seen[key] = true
+				counts[key]++
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	tags := make([]TagJSON, 0, len(keys))
+	for _, key := range keys {
+		var spelling string
+		for s, uses := range spellings[key] {
+			if spelling == "" || uses > spellings[key][spelling] || uses == spellings[key][spelling] && s < spelling {
+				spelling = s
+			}
+		}
+		tags = append(tags, TagJSON{Tag: spelling, Count: counts[key]})
+	}
+	return tags
+}
+
+// hasTag reports whether post carries tag, compared through normalizeTag.
+func hasTag(post *Post, tag string) bool {
+	tag = normalizeTag(tag)
+	for _, t := range post.Tags {
+		if normalizeTag(t) == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeTag is the form in which tags are compared, so "Café", "cafe"
+// and "CAFE" are the same tag. It drops diacritics and folds case the way
+// normalizeTag in static/search.js does with Unicode NFD decomposition.
+func normalizeTag(tag string) string {
+	// A chain keeps state between calls, so each call gets its own.
+	stripMarks := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	stripped, _, err := transform.String(stripMarks, strings.TrimSpace(tag))
+	if err != nil {
+		stripped = strings.TrimSpace(tag)
+	}
+	return foldCase(stripped)
+}
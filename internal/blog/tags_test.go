@@ -0,0 +1,80 @@
+package blog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCoOccurringTags(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"a.md": "---\ntitle: A\ndate: 2024-01-01\ntags: go, web, testing\n---\nA.",
+		"b.md": "---\ntitle: B\ndate: 2024-01-02\ntags: go, web, api\n---\nB.",
+		"c.md": "---\ntitle: C\ndate: 2024-01-03\ntags: Go, api, cli\n---\nC.",
+		"d.md": "---\ntitle: D\ndate: 2024-01-04\ntags: web, design\n---\nD.",
+		"e.md": "---\ntitle: E\ndate: 2024-01-05\ntags: go, testing\n---\nE.",
+	})
+
+	got := blog.coOccurringTags("go", 10)
+	want := []string{"api", "testing", "web", "cli"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("coOccurringTags(go) = %v, want %v", got, want)
+	}
+
+	if got := blog.coOccurringTags("go", 2); !reflect.DeepEqual(got, []string{"api", "testing"}) {
+		t.Errorf("Expected the top 2 tags, got %v", got)
+	}
+	if got := blog.coOccurringTags("missing", 5); len(got) != 0 {
+		t.Errorf("Expected no related tags for an unknown tag, got %v", got)
+	}
+}
+
+func TestSearchPageRelatedTags(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"a.md": "---\ntitle: A\ndate: 2024-01-01\ntags: go, web\n---\nA.",
+	})
+
+	rec := httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search/?q=go", nil))
+	if !strings.Contains(rec.Body.String(), `<a href="/search/?q=web" class="tag">web</a>`) {
+		t.Errorf("Expected a link to the related tag web on the go tag page")
+	}
+}
+
+func TestNormalizeTag(t *testing.T) {
+	tests := map[string]string{
+		"go":       "go",
+		"GO":       "go",
+		" Café ":   "cafe",
+		"Café":    "cafe",
+		"İstanbul": "istanbul",
+		"Şişli":    "sisli",
+		"Ωmega":    "ωmega",
+	}
+	for tag, want := range tests {
+		if got := normalizeTag(tag); got != want {
+			t.Errorf("normalizeTag(%q) = %q, want %q", tag, got, want)
+		}
+	}
+}
+
+func TestSearchTagIgnoresCaseAndDiacritics(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"coffee.md": "---\ntitle: Coffee\ndate: 2024-01-01\ntags: Café\n---\nEspresso.",
+		"golang.md": "---\ntitle: Golang\ndate: 2024-01-02\ntags: go, Café\n---\nGoroutines.",
+		"other.md":  "---\ntitle: Other\ndate: 2024-01-03\ntags: misc\n---\nNothing here.",
+	})
+
+	results := blog.search("cafe")
+	if len(results) != 2 {
+		t.Fatalf("Expected cafe to match both posts tagged Café, got %d", len(results))
+	}
+	if results := blog.search("GO"); len(results) != 1 || results[0].Slug != "golang" {
+		t.Errorf("Expected GO to match the post tagged go, got %v", results)
+	}
+	if got := blog.coOccurringTags("CAFE", 5); !reflect.DeepEqual(got, []string{"go"}) {
+		t.Errorf("Expected go as the tag related to CAFE, got %v", got)
+	}
+}
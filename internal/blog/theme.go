@@ -0,0 +1,31 @@
+package blog
+
+import (
+	"fmt"
+	"html/template"
+	"path/filepath"
+)
+
+// LoadTheme parses the *.html files in dir over the current templates, so a
+// theme only needs to contain the pages it changes. On error the current
+// templates are kept. Call it before rendering any page: html/template
+// cannot copy templates that have already been executed.
+func (b *Blog) LoadTheme(dir string) error {
+	var themed *template.Template
+	if b.templates != nil {
+		clone, err := b.templates.Clone()
+		if err != nil {
+			return fmt.Errorf("failed to copy templates: %w", err)
+		}
+		themed = clone
+	} else {
+		themed = template.New("").Funcs(b.funcs)
+	}
+
+	themed, err := themed.ParseGlob(filepath.Join(dir, "*.html"))
+	if err != nil {
+		return fmt.Errorf("failed to parse theme %s: %w", dir, err)
+	}
+	b.templates = themed
+	return nil
+}
@@ -0,0 +1,58 @@
+package blog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadThemeOverridesTemplates(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHello.",
+	})
+
+	dir := t.TempDir()
+	theme := `<html><body class="custom-theme">{{range .Posts}}{{.Title}}{{end}}</body></html>`
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte(theme), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := blog.LoadTheme(dir); err != nil {
+		t.Fatalf("Failed to load theme: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `class="custom-theme"`) || !strings.Contains(body, "Hello") {
+		t.Errorf("Expected the themed index.html, got %s", body)
+	}
+
+	// Pages the theme does not define keep the embedded templates.
+	rec = httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/post/hello/", nil))
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "custom-theme") {
+		t.Errorf("Expected the embedded post.html, got %d", rec.Code)
+	}
+}
+
+func TestLoadThemeFallsBackOnParseError(t *testing.T) {
+	blog := newTestBlog(t, map[string]string{
+		"hello.md": "---\ntitle: Hello\ndate: 2024-01-27\n---\nHello.",
+	})
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte("{{.Broken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := blog.LoadTheme(dir); err == nil {
+		t.Fatalf("Expected an error for an invalid theme")
+	}
+
+	rec := httptest.NewRecorder()
+	blog.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected embedded templates to keep working, got %d", rec.Code)
+	}
+}
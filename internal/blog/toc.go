@@ -0,0 +1,73 @@
+package blog
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// TOCEntry is a heading in a post's table of contents. Offset is the
+// heading's position in the markdown source, in characters, and Progress is
+// that position as a fraction of the whole post, so client scripts can map
+// the scroll position to a section without re-parsing the page.
+type TOCEntry struct {
+	Level    int
+	Text     string
+	ID       string
+	Offset   int
+	Progress float64
+}
+
+// tableOfContents collects the headings of a parsed markdown document.
+func tableOfContents(doc ast.Node, source []byte) []TOCEntry {
+	total := utf8.RuneCount(source)
+	var entries []TOCEntry
+
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := n.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+
+		var offset int
+		if lines := heading.Lines(); lines.Len() > 0 {
+			offset = utf8.RuneCount(source[:lines.At(0).Start])
+		}
+		var progress float64
+		if total > 0 {
+			progress = float64(offset) / float64(total)
+		}
+
+		id, _ := heading.AttributeString("id")
+		idStr, _ := id.([]byte)
+		entries = append(entries, TOCEntry{
+			Level:    heading.Level,
+			Text:     nodeText(heading, source),
+			ID:       string(idStr),
+			Offset:   offset,
+			Progress: progress,
+		})
+		return ast.WalkSkipChildren, nil
+	})
+
+	return entries
+}
+
+// nodeText concatenates the text segments beneath n.
+func nodeText(n ast.Node, source []byte) string {
+	var sb strings.Builder
+	ast.Walk(n, func(child ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch t := child.(type) {
+		case *ast.Text:
+			sb.Write(t.Segment.Value(source))
+		case *ast.String:
+			sb.Write(t.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return sb.String()
+}
@@ -0,0 +1,35 @@
+package blog
+
+import (
+	"embed"
+	"testing"
+)
+
+func TestTableOfContentsOffsets(t *testing.T) {
+	blog, _ := NewBlog(embed.FS{}, embed.FS{}, embed.FS{})
+	content := "---\ntitle: Long Post\ndate: 2024-01-27\n---\n" +
+		"# Introduction\nSome opening words.\n\n" +
+		"## Background\nA longer paragraph with plenty of text in it.\n\n" +
+		"### Details\nMore text.\n\n" +
+		"## Conclusion\nThe end."
+
+	post, err := blog.parsePost("long-post.md", content)
+	if err != nil {
+		t.Fatalf("Failed to parse post: %v", err)
+	}
+
+	if len(post.TOC) != 4 {
+		t.Fatalf("Expected 4 headings, got %d", len(post.TOC))
+	}
+	if post.TOC[1].Text != "Background" || post.TOC[1].ID != "background" || post.TOC[1].Level != 2 {
+		t.Errorf("Unexpected entry %+v", post.TOC[1])
+	}
+	for i := 1; i < len(post.TOC); i++ {
+		if post.TOC[i].Offset <= post.TOC[i-1].Offset {
+			t.Errorf("Expected offsets to increase, got %d after %d", post.TOC[i].Offset, post.TOC[i-1].Offset)
+		}
+		if post.TOC[i].Progress <= post.TOC[i-1].Progress || post.TOC[i].Progress >= 1 {
+			t.Errorf("Expected progress to increase within [0, 1), got %f after %f", post.TOC[i].Progress, post.TOC[i-1].Progress)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package blog
+
+import (
+	"net/http"
+	"runtime"
+)
+
+// BuildInfo identifies the deployed build. main sets it from variables
+// injected with -ldflags -X.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+}
+
+// buildInfo returns b.Build with defaults for anything not injected at
+// build time.
+func (b *Blog) buildInfo() BuildInfo {
+	info := b.Build
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildDate == "" {
+		info.BuildDate = "unknown"
+	}
+	info.GoVersion = runtime.Version()
+	return info
+}
+
+func (b *Blog) handleVersion(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, b.buildInfo())
+}
@@ -0,0 +1,72 @@
+package blog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// webSubFeeds are the feeds that advertise Config.WebSubHub, and so the
+// ones announced to it.
+var webSubFeeds = []string{"atom.xml", "rss.xml"}
+
+// hubClient sends WebSub pings. Hubs answer publish requests right away
+// and fetch the feed later, so a slow one is given up on.
+var hubClient = &http.Client{Timeout: 10 * time.Second}
+
+// validHubURL reports whether hub is an absolute http(s) URL.
+func validHubURL(hub string) bool {
+	u, err := url.Parse(hub)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// PingHub tells Config.WebSubHub that the feeds changed, so it can push
+// them to subscribers instead of waiting for them to poll. It does nothing
+// when no hub is configured. Call it once the export is live, since the
+// hub fetches the feeds from Config.BaseURL as soon as it is pinged.
+func (b *Blog) PingHub(ctx context.Context) error {
+	hub := b.Config.WebSubHub
+	if hub == "" {
+		return nil
+	}
+
+	var errs []error
+	for _, name := range webSubFeeds {
+		feedURL := b.Config.BaseURL + "/" + name
+		if err := pingHub(ctx, hub, feedURL); err != nil {
+			errs = append(errs, fmt.Errorf("pinging %s for %s: %w", hub, feedURL, err))
+			continue
+		}
+		slog.Info("Notified WebSub hub", "hub", hub, "feed", feedURL)
+	}
+	return errors.Join(errs...)
+}
+
+// pingHub sends a WebSub publish request for feedURL. Hubs accept it with
+// any 2xx status; anything else is reported with the start of its body,
+// which usually says what the hub objected to.
+func pingHub(ctx context.Context, hub, feedURL string) error {
+	form := url.Values{"hub.mode": {"publish"}, "hub.url": {feedURL}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hub, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := hubClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("hub answered %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package blog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPingHub(t *testing.T) {
+	var mu sync.Mutex
+	var pinged []string
+	status := http.StatusNoContent
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Method != http.MethodPost || r.PostForm.Get("hub.mode") != "publish" {
+			t.Errorf("Expected a publish form POST, got %s %v", r.Method, r.PostForm)
+		}
+		mu.Lock()
+		pinged = append(pinged, r.PostForm.Get("hub.url"))
+		mu.Unlock()
+		w.WriteHeader(status)
+		if status != http.StatusNoContent {
+			w.Write([]byte("feed not allowed"))
+		}
+	}))
+	defer hub.Close()
+
+	blog := newTestBlog(t, map[string]string{
+		"go.md": "---\ntitle: Learning Go\ndate: 2024-01-01\n---\nNotes on Go.",
+	})
+	if err := blog.PingHub(context.Background()); err != nil || len(pinged) != 0 {
+		t.Fatalf("Expected no ping without a hub, got %v and %v", err, pinged)
+	}
+
+	blog.Config.BaseURL = "https://blog.example.com"
+	blog.Config.WebSubHub = hub.URL
+	if err := blog.PingHub(context.Background()); err != nil {
+		t.Fatalf("Expected the ping to succeed, got %v", err)
+	}
+	want := []string{"https://blog.example.com/atom.xml", "https://blog.example.com/rss.xml"}
+	if len(pinged) != len(want) || pinged[0] != want[0] || pinged[1] != want[1] {
+		t.Errorf("Expected the hub to be pinged for %v, got %v", want, pinged)
+	}
+
+	for _, feed := range [][]byte{blog.atomXML(), blog.rssXML()} {
+		if !strings.Contains(string(feed), `href="`+hub.URL+`" rel="hub"`) {
+			t.Errorf("Expected the feed to advertise the hub, got %s", feed)
+		}
+	}
+
+	status = http.StatusBadRequest
+	err := blog.PingHub(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "400") || !strings.Contains(err.Error(), "feed not allowed") {
+		t.Errorf("Expected an error with the hub's answer, got %v", err)
+	}
+
+	if got := applyConfigDefaults(Config{WebSubHub: "pubsubhubbub.appspot.com"}).WebSubHub; got != "" {
+		t.Errorf("Expected a hub without a scheme to be ignored, got %q", got)
+	}
+}
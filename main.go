@@ -1,10 +1,18 @@
 package main
 
 import (
+	"context"
 	"embed"
+	"errors"
 	"flag"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	_ "time/tzdata" // timezone in config.yaml works without system zoneinfo, e.g. on Lambda
 
 	"github.com/cenkcorapci/my-blog/internal/blog"
 )
@@ -18,29 +26,162 @@ var staticFS embed.FS
 //go:embed blog/*
 var blogFS embed.FS
 
+// Set at build time with -ldflags "-X main.version=... -X main.commit=... -X main.date=...".
+var (
+	version = "dev"
+	commit  = "unknown"
+	date    = "unknown"
+)
+
 func main() {
-	serve := flag.Bool("serve", false, "Serve the generated site locally")
-	distDir := flag.String("dist", "dist", "Directory to output the static site")
+	serve := flag.Bool("serve", false, "Serve the site locally")
+	distDir := flag.String("dist", "dist", "Directory to output the static site; must be missing, empty, or a previous export")
 	port := flag.String("port", "8080", "Port to serve on (only used with -serve)")
+	themeDir := flag.String("templates", "", "Directory of templates overriding the embedded ones")
+	statsFile := flag.String("stats", "stats.json", "File keeping view counts across restarts (only used with -serve)")
+	lint := flag.Bool("lint", false, "Check every post for problems, print them, and exit non-zero if any are found")
+	newPost := flag.String("new", "", "Create a new post in the content directory (blog/ by default) with the given title and exit")
+	minifyHTML := flag.Bool("minify", true, "Minify exported HTML pages (overrides minify_html in config.yaml)")
+	contentDir := flag.String("content", "", "Directory of markdown posts on disk to publish alongside the embedded ones; they replace embedded posts with the same slug")
+	ogImages := flag.Bool("og-images", false, "Generate social preview images for posts without an image: (slower)")
+	keepGoing := flag.Bool("keep-going", false, "Export the posts that load even if others fail to read or parse")
+	force := flag.Bool("force", false, "Rewrite every exported file, even ones unchanged since the previous export; also replaces an export that predates its .blog-export marker")
+	precompress := flag.Bool("precompress", false, "Also write .gz variants of exported HTML, CSS, JS, JSON and XML files")
+	previewFuture := flag.Bool("preview-future", false, "Publish posts dated in the future, e.g. to preview them locally (overrides preview_future in config.yaml)")
+	searchIndex := flag.String("search-index", "", "Directory of a previous export whose search-index.json is reused instead of indexing the posts again; it must come from the same posts")
+	ping := flag.Bool("ping", false, "After exporting, notify websub_hub in config.yaml that the feeds changed; run once the site is deployed")
 	flag.Parse()
 
+	slog.SetDefault(blog.NewLoggerFromEnv())
+
+	// Posts are embedded from blog/; with content_dir set in config.yaml,
+	// change the go:embed pattern above to match.
 	b, err := blog.NewBlog(templatesFS, staticFS, blogFS)
 	if err != nil {
-		log.Fatalf("Error initializing blog: %v", err)
+		slog.Error("Error initializing blog", "err", err)
+		os.Exit(1)
 	}
 
-	// Always load posts and generate the site
+	if *newPost != "" {
+		path, err := blog.CreatePost(b.Config.ContentDir, *newPost, time.Now())
+		if err != nil {
+			slog.Error("Error creating post", "err", err)
+			os.Exit(1)
+		}
+		fmt.Println(path)
+		return
+	}
+	b.Build = blog.BuildInfo{Version: version, Commit: commit, BuildDate: date}
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "minify":
+			b.Config.MinifyHTML = minifyHTML
+		case "og-images":
+			b.Config.OGImages = *ogImages
+		case "precompress":
+			b.Config.Precompress = *precompress
+		case "preview-future":
+			b.Config.PreviewFuture = *previewFuture
+		}
+	})
+	if *themeDir != "" {
+		if err := b.LoadTheme(*themeDir); err != nil {
+			slog.Warn("Error loading theme, using embedded templates", "err", err)
+		}
+	}
+
+	// Redirects come first so links to moved posts are not reported as broken.
+	if err := b.LoadRedirects("redirects.yaml"); err != nil {
+		slog.Warn("Error loading redirects", "err", err)
+	}
+
+	if *lint {
+		issues := b.Lint()
+		for _, issue := range issues {
+			fmt.Println(issue)
+		}
+		if len(issues) > 0 {
+			fmt.Printf("%d problem(s) found\n", len(issues))
+			os.Exit(1)
+		}
+		fmt.Println("No problems found")
+		return
+	}
+
+	// Always load posts and generate the site. Broken posts fail the build,
+	// so CI catches them, unless -keep-going publishes the rest.
+	loadFailed := func(err error, args ...any) {
+		if *keepGoing {
+			slog.Warn("Skipping posts that failed to load", append(args, "err", err)...)
+			return
+		}
+		slog.Error("Error loading posts", append(args, "err", err)...)
+		os.Exit(1)
+	}
+	if *searchIndex != "" {
+		if err := b.LoadPrebuiltIndex(os.DirFS(*searchIndex)); err != nil {
+			slog.Warn("Error loading search index, indexing posts instead", "err", err)
+		}
+	}
 	if err := b.LoadPosts(); err != nil {
-		log.Fatal(err)
+		loadFailed(err)
+	}
+	if *contentDir != "" {
+		if err := b.LoadPostsFromDir(*contentDir); err != nil {
+			loadFailed(err, "dir", *contentDir)
+		}
 	}
 
-	b.Export(*distDir)
+	if _, err := b.ExportIncremental(*distDir, *force); err != nil {
+		slog.Error("Error exporting site", "err", err)
+		os.Exit(1)
+	}
+	if *ping {
+		// Subscribers poll eventually, so a failed ping doesn't fail the build.
+		if err := b.PingHub(context.Background()); err != nil {
+			slog.Warn("Error notifying WebSub hub", "err", err)
+		}
+	}
 
 	if *serve {
-		log.Printf("Serving %s on http://localhost:%s", *distDir, *port)
-		err := http.ListenAndServe(":"+*port, http.FileServer(http.Dir(*distDir)))
-		if err != nil {
-			log.Fatal(err)
+		if err := b.LoadStats(*statsFile); err != nil {
+			slog.Warn("Error loading view counts", "err", err)
+		}
+		if b.Config.LogSearches && b.Config.SearchLog != "" {
+			searchLog, err := os.OpenFile(b.Config.SearchLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+			if err != nil {
+				slog.Error("Error opening search log", "err", err)
+				os.Exit(1)
+			}
+			defer searchLog.Close()
+			b.SetSearchLog(searchLog)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		server := &http.Server{Addr: ":" + *port, Handler: b.Router()}
+		shutdownDone := make(chan struct{})
+		go func() {
+			defer close(shutdownDone)
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			server.Shutdown(shutdownCtx)
+		}()
+
+		slog.Info("Serving", "url", "http://localhost:"+*port)
+		err := server.ListenAndServe()
+		if errors.Is(err, http.ErrServerClosed) {
+			// Let in-flight requests finish counting views before saving.
+			<-shutdownDone
+		}
+		if err := b.SaveStats(*statsFile); err != nil {
+			slog.Error("Error saving view counts", "err", err)
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Server stopped", "err", err)
+			os.Exit(1)
 		}
 	}
 }
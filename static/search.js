@@ -8,10 +8,20 @@
  * all search operations in the browser.
  */
 
+/**
+ * Normalize a tag for comparison, so "Café", "cafe" and "CAFE" are the
+ * same tag. Mirrors normalizeTag in the Go generator: diacritics are
+ * dropped through NFD decomposition and case is folded.
+ */
+function normalizeTag(tag) {
+    return tag.trim().normalize('NFD').replace(/\p{M}/gu, '').toLowerCase();
+}
+
 class BlogSearch {
     constructor() {
         this.posts = [];
         this.invertedIndex = {};
+        this.infixSearch = false;
         this.initialized = false;
     }
 
@@ -35,6 +45,7 @@ class BlogSearch {
                 tags: post.tags || []
             }));
             this.invertedIndex = data.invertedIndex || {};
+            this.infixSearch = data.infixSearch === true;
             this.initialized = true;
             console.log('Search index loaded successfully');
         } catch (error) {
@@ -46,11 +57,12 @@ class BlogSearch {
     }
 
     /**
-     * Tokenize text into searchable words
+     * Tokenize text into searchable words in any script, folding case the
+     * same way as the Go indexer (dotted capital I becomes a plain i)
      */
     tokenize(text) {
         if (!text) return [];
-        return (text.match(/[a-zA-Z0-9]+/g) || []).map(w => w.toLowerCase());
+        return (text.match(/[\p{L}\p{N}\p{M}]+/gu) || []).map(w => w.replace(/İ/g, 'I').toLowerCase());
     }
 
     /**
@@ -65,8 +77,9 @@ class BlogSearch {
         if (!query) return [];
 
         // First, check for exact tag match
+        const tagQuery = normalizeTag(query);
         const tagMatches = this.posts.filter(post =>
-            (post.tags || []).some(tag => tag.toLowerCase() === query)
+            (post.tags || []).some(tag => normalizeTag(tag) === tagQuery)
         );
 
         if (tagMatches.length > 0) {
@@ -77,11 +90,17 @@ class BlogSearch {
         const words = this.tokenize(query);
         if (words.length === 0) return [];
 
-        let matchingPostIds = null;
+        // Words no post contains, such as typos, are dropped rather than
+        // emptying the results, as the server's search does
+        const postings = words
+            .map(word => this.infixSearch
+                ? this.infixPostIds(word)
+                : (this.invertedIndex[word] || []))
+            .filter(postIds => postIds.length > 0);
 
-        for (const word of words) {
-            const postIds = this.invertedIndex[word] || [];
+        let matchingPostIds = null;
 
+        for (const postIds of postings) {
             if (matchingPostIds === null) {
                 matchingPostIds = new Set(postIds);
             } else {
@@ -105,34 +124,73 @@ class BlogSearch {
     }
 
     /**
-     * Get search suggestions based on partial query
+     * Collect post IDs for every indexed word containing the given fragment
+     * @param {string} fragment - Part of a word
+     * @returns {Array} Array of post IDs
+     */
+    infixPostIds(fragment) {
+        const ids = new Set();
+        for (const [word, postIds] of Object.entries(this.invertedIndex)) {
+            if (word.includes(fragment)) {
+                postIds.forEach(id => ids.add(id));
+            }
+        }
+        return Array.from(ids);
+    }
+
+    /**
+     * Get search suggestions based on partial query, most relevant first:
+     * matching tags, then titles, then indexed words from post bodies
+     * ranked by how many posts contain them. Duplicates are dropped
+     * case-insensitively, keeping the first (highest ranked) casing.
      * @param {string} query - The partial search query
+     * @param {number} page - The 1-based page of suggestions to return
+     * @param {number} limit - The number of suggestions per page
      * @returns {Array} Array of suggestion strings
      */
-    getSuggestions(query) {
+    getSuggestions(query, page = 1, limit = 10) {
         if (!this.initialized || !query) return [];
 
         query = query.trim().toLowerCase();
         if (query.length < 2) return [];
 
-        const suggestions = new Set();
+        const suggestions = new Map();
+        const add = text => {
+            const key = text.toLowerCase();
+            if (!suggestions.has(key)) {
+                suggestions.set(key, text);
+            }
+        };
 
+        // Suggest from tags, most used first
+        const tagQuery = normalizeTag(query);
+        const tagCounts = new Map();
         for (const post of this.posts) {
-            // Suggest from tags
-            const tags = post.tags || [];
-            for (const tag of tags) {
-                if (tag.toLowerCase().startsWith(query)) {
-                    suggestions.add(tag);
+            for (const tag of post.tags || []) {
+                if (normalizeTag(tag).startsWith(tagQuery)) {
+                    tagCounts.set(tag, (tagCounts.get(tag) || 0) + 1);
                 }
             }
+        }
+        [...tagCounts.entries()]
+            .sort((a, b) => b[1] - a[1] || a[0].localeCompare(b[0]))
+            .forEach(([tag]) => add(tag));
 
-            // Suggest from titles
+        // Suggest from titles, in post order
+        for (const post of this.posts) {
             if (post.title.toLowerCase().includes(query)) {
-                suggestions.add(post.title);
+                add(post.title);
             }
         }
 
-        return Array.from(suggestions).slice(0, 10);
+        // Suggest from body terms, most frequent first
+        Object.keys(this.invertedIndex)
+            .filter(word => word.startsWith(query))
+            .sort((a, b) => this.invertedIndex[b].length - this.invertedIndex[a].length || a.localeCompare(b))
+            .forEach(add);
+
+        const start = (Math.max(page, 1) - 1) * limit;
+        return Array.from(suggestions.values()).slice(start, start + limit);
     }
 
     /**
@@ -313,5 +371,5 @@ if (typeof window !== 'undefined') {
 }
 
 if (typeof module !== 'undefined' && module.exports) {
-    module.exports = { BlogSearch, blogSearch };
+    module.exports = { BlogSearch, blogSearch, normalizeTag };
 }
@@ -26,6 +26,10 @@ describe('BlogSearch', () => {
         expect(blogSearch.tokenize('Hello World!')).toEqual(['hello', 'world']);
     });
 
+    test('tokenize should keep non-ASCII words whole', () => {
+        expect(blogSearch.tokenize('Café Ωmega İstanbul')).toEqual(['café', 'ωmega', 'istanbul']);
+    });
+
     test('search should return exact tag matches first', () => {
         const results = blogSearch.search('go');
         expect(results).toHaveLength(1);
@@ -52,12 +56,38 @@ describe('BlogSearch', () => {
         expect(results[0].id).toBe('post-2');
     });
 
+    test('search should match tags ignoring case and diacritics', () => {
+        blogSearch.posts[1].tags = ['Café'];
+        expect(blogSearch.search('cafe').map(p => p.id)).toEqual(['post-2']);
+        expect(blogSearch.search('GO').map(p => p.id)).toEqual(['post-1']);
+        expect(blogSearch.getSuggestions('caf')).toContain('Café');
+    });
+
     test('getSuggestions should return matching tags and titles', () => {
         const suggestions = blogSearch.getSuggestions('go');
         expect(suggestions).toContain('go');
         expect(suggestions).toContain('Go Programming');
     });
 
+    test('getSuggestions should rank tags before titles and body terms', () => {
+        blogSearch.invertedIndex['gopher'] = ['post-1', 'post-2'];
+        blogSearch.invertedIndex['golang'] = ['post-2'];
+        const suggestions = blogSearch.getSuggestions('go');
+        expect(suggestions).toEqual(['go', 'Go Programming', 'gopher', 'golang']);
+    });
+
+    test('getSuggestions should de-duplicate case-insensitively keeping tag casing', () => {
+        blogSearch.posts[1].tags = ['JavaScript'];
+        const suggestions = blogSearch.getSuggestions('java');
+        expect(suggestions).toEqual(['JavaScript', 'JavaScript Guide']);
+    });
+
+    test('getSuggestions should paginate', () => {
+        blogSearch.invertedIndex['gopher'] = ['post-1'];
+        expect(blogSearch.getSuggestions('go', 1, 2)).toEqual(['go', 'Go Programming']);
+        expect(blogSearch.getSuggestions('go', 2, 2)).toEqual(['gopher']);
+    });
+
     test('sortByDate should sort newest first', () => {
         const sorted = blogSearch.sortByDate(mockData.posts);
         expect(sorted[0].id).toBe('post-2');
@@ -69,8 +99,24 @@ describe('BlogSearch', () => {
         expect(results).toEqual([]);
     });
 
+    test('search should ignore words no post contains', () => {
+        expect(blogSearch.search('xyzzy guide').map(p => p.id)).toEqual(['post-2']);
+        expect(blogSearch.search('guide xyzzy').map(p => p.id)).toEqual(['post-2']);
+        expect(blogSearch.search('xyzzy plugh')).toEqual([]);
+        expect(blogSearch.search('xyzzy go guide')).toEqual([]);
+    });
+
     test('search should return empty array for empty query', () => {
         expect(blogSearch.search('')).toEqual([]);
         expect(blogSearch.search('   ')).toEqual([]);
     });
+
+    test('search should match word fragments in infix mode', () => {
+        expect(blogSearch.search('gram')).toEqual([]);
+
+        blogSearch.infixSearch = true;
+        const results = blogSearch.search('gram');
+        expect(results).toHaveLength(1);
+        expect(results[0].id).toBe('post-1');
+    });
 });